@@ -0,0 +1,244 @@
+// Package asset_mirror copies the assets referenced in CMS responses into an own store and rewrites the URLs.
+//
+// Mirror wraps the HTTPClient of storyblok.Client, so GetPageAsJSON, GetPage and everything built on them
+// see and cache the rewritten URLs:
+//
+//	store := asset_mirror.NewFileStore("/var/www/assets", "https://assets.example.com")
+//	client := storyblok.NewClient(ctx, token, emptyCacheToken, cache, asset_mirror.New(&http.Client{}, store))
+//
+// Assets are stored under the sha256 of their content. The source URL of every stored asset is recorded
+// in the store as well, so assets are downloaded once, also across restarts and instances.
+package asset_mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+var _ storyblok.HTTPClient = &Mirror{}
+
+// Store keeps the mirrored assets. Implementations for S3 compatible buckets map Put and Get to
+// PutObject and GetObject and URL to the public bucket or CDN URL.
+type Store interface {
+	// Put stores data under name, names are slash separated paths
+	Put(ctx context.Context, name string, data []byte) error
+	// Get returns the data stored under name or an error wrapping fs.ErrNotExist
+	Get(ctx context.Context, name string) ([]byte, error)
+	// URL returns the public URL of name
+	URL(name string) string
+}
+
+type Mirror struct {
+	Next  storyblok.HTTPClient // fetches CMS responses and assets
+	Store Store
+	Hosts []string // ["a.storyblok.com"], asset hosts to mirror
+
+	mu   sync.Mutex
+	urls map[string]*mirrored
+}
+
+type mirrored struct {
+	once sync.Once
+	url  string
+	err  error
+}
+
+func New(next storyblok.HTTPClient, store Store) *Mirror {
+	return &Mirror{
+		Next:  next,
+		Store: store,
+		Hosts: []string{"a.storyblok.com"},
+		urls:  map[string]*mirrored{},
+	}
+}
+
+// Do forwards req and rewrites the asset URLs of successful CDN responses.
+// Assets that fail to mirror keep their original URL.
+func (m *Mirror) Do(req *http.Request) (*http.Response, error) {
+	resp, err := m.Next.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(req.URL.Path, "/cdn/") {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = m.Rewrite(req.Context(), body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// Rewrite mirrors the assets referenced in body and returns it with the URLs of the store
+func (m *Mirror) Rewrite(ctx context.Context, body []byte) []byte {
+	return storyblok.AssetURLPattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		u := string(match)
+		if !m.isAssetHost(string(storyblok.AssetURLPattern.FindSubmatch(match)[1])) {
+			return match
+		}
+		mirroredURL, err := m.mirror(ctx, u)
+		if err != nil {
+			slog.WarnContext(ctx, "asset_mirror - mirror", slog.String("url", u), slog.Any("err", err))
+			return match
+		}
+		return []byte(mirroredURL)
+	})
+}
+
+func (m *Mirror) isAssetHost(host string) bool {
+	for _, h := range m.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// mirror returns the store URL of the asset at u, downloading it when it is not in the store yet
+func (m *Mirror) mirror(ctx context.Context, u string) (string, error) {
+	if strings.HasPrefix(u, "//") {
+		u = "https:" + u
+	}
+	m.mu.Lock()
+	if m.urls == nil {
+		m.urls = map[string]*mirrored{}
+	}
+	entry, ok := m.urls[u]
+	if !ok {
+		entry = &mirrored{}
+		m.urls[u] = entry
+	}
+	m.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.url, entry.err = m.fetch(ctx, u)
+	})
+	if entry.err != nil {
+		// retried with the next response
+		m.mu.Lock()
+		delete(m.urls, u)
+		m.mu.Unlock()
+	}
+	return entry.url, entry.err
+}
+
+func (m *Mirror) fetch(ctx context.Context, u string) (string, error) {
+	sum := sha256.Sum256([]byte(u))
+	sourceName := "sources/" + hex.EncodeToString(sum[:])
+	name, err := m.Store.Get(ctx, sourceName)
+	if err == nil && len(name) > 0 {
+		return m.Store.URL(string(name)), nil
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := m.Next.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("asset_mirror: %s: status: %d", u, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// the file name stays readable, image service URLs are named after the original
+	base, _, _ := strings.Cut(req.URL.Path, "/m/")
+	contentSum := sha256.Sum256(data)
+	assetName := "assets/" + hex.EncodeToString(contentSum[:]) + "/" + path.Base(base)
+	if _, err := m.Store.Get(ctx, assetName); errors.Is(err, fs.ErrNotExist) {
+		if err := m.Store.Put(ctx, assetName, data); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+	if err := m.Store.Put(ctx, sourceName, []byte(assetName)); err != nil {
+		return "", err
+	}
+	return m.Store.URL(assetName), nil
+}
+
+var _ Store = &FileStore{}
+
+// FileStore keeps assets in a directory served under BaseURL
+type FileStore struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewFileStore(dir string, baseURL string) *FileStore {
+	return &FileStore{Dir: dir, BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *FileStore) file(name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("asset_mirror: invalid name %q", name)
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(name)), nil
+}
+
+func (s *FileStore) Put(ctx context.Context, name string, data []byte) error {
+	file, err := s.file(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (s *FileStore) Get(ctx context.Context, name string) ([]byte, error) {
+	file, err := s.file(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(file)
+}
+
+func (s *FileStore) URL(name string) string {
+	return s.BaseURL + "/" + name
+}
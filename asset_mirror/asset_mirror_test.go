@@ -0,0 +1,96 @@
+package asset_mirror_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dryaf/headless_cms/asset_mirror"
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCDN struct {
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func (f *fakeCDN) Do(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.requests[req.URL.Host+req.URL.Path]++
+	f.mu.Unlock()
+	body := ""
+	switch req.URL.Host + req.URL.Path {
+	case "api.storyblok.com/v2/cdn/stories/home":
+		body = `{"story": {"content": {
+			"image": {"filename": "https://a.storyblok.com/f/1/100x100/abc/hero.png"},
+			"thumb": "https://a.storyblok.com/f/1/100x100/abc/hero.png/m/50x50",
+			"copy": "//a.storyblok.com/f/1/100x100/def/copy.png",
+			"webp": "https://a.storyblok.com/f/1/100x100/abc/hero.png/m/50x50/filters:format(webp)",
+			"broken": "https://a.storyblok.com/f/1/missing.png",
+			"other": "https://example.com/x.png"
+		}}}`
+	case "a.storyblok.com/f/1/100x100/abc/hero.png", "a.storyblok.com/f/1/100x100/def/copy.png":
+		body = "png"
+	case "a.storyblok.com/f/1/100x100/abc/hero.png/m/50x50":
+		body = "small png"
+	case "a.storyblok.com/f/1/100x100/abc/hero.png/m/50x50/filters:format(webp)":
+		body = "small webp"
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	cdn := &fakeCDN{requests: map[string]int{}}
+	dir := t.TempDir()
+	store := asset_mirror.NewFileStore(dir, "https://assets.example.com/")
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), asset_mirror.New(cdn, store))
+
+	page, err := client.GetPage(ctx, "home", "published", "")
+	require.NoError(t, err)
+	content := page["story"].(map[string]any)["content"].(map[string]any)
+
+	image := content["image"].(map[string]any)["filename"].(string)
+	assert.True(t, strings.HasPrefix(image, "https://assets.example.com/assets/"), image)
+	assert.True(t, strings.HasSuffix(image, "/hero.png"), image)
+	// stored under the hash of the content
+	assert.Equal(t, path.Dir(image), path.Dir(content["copy"].(string)))
+	assert.NotEqual(t, image, content["thumb"])
+	assert.True(t, strings.HasSuffix(content["thumb"].(string), "/hero.png"))
+	assert.Equal(t, "https://a.storyblok.com/f/1/missing.png", content["broken"])
+	webp := content["webp"].(string)
+	assert.True(t, strings.HasSuffix(webp, "/hero.png"), webp)
+	assert.NotEqual(t, path.Dir(content["thumb"].(string)), path.Dir(webp))
+	assert.Equal(t, "https://example.com/x.png", content["other"])
+
+	data, err := store.Get(ctx, strings.TrimPrefix(image, "https://assets.example.com/"))
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+	data, err = store.Get(ctx, strings.TrimPrefix(webp, "https://assets.example.com/"))
+	require.NoError(t, err)
+	assert.Equal(t, "small webp", string(data))
+
+	// a new mirror on the same store does not download again
+	client = storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), asset_mirror.New(cdn, store))
+	_, err = client.GetPageAsJSON(ctx, "home", "published", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cdn.requests["a.storyblok.com/f/1/100x100/abc/hero.png"])
+	assert.Equal(t, 2, cdn.requests["a.storyblok.com/f/1/missing.png"])
+}
+
+func TestFileStoreInvalidName(t *testing.T) {
+	store := asset_mirror.NewFileStore(t.TempDir(), "https://assets.example.com")
+	require.Error(t, store.Put(context.Background(), "../x", []byte("x")))
+	_, err := store.Get(context.Background(), "/etc/passwd")
+	require.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package redis_bus
+
+import (
+	"context"
+	"encoding/json"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ headless_cms.InvalidationBus = &Bus{}
+
+const DefaultChannel = "headless_cms:invalidation"
+
+// Bus broadcasts invalidations over Redis pub/sub.
+type Bus struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+func New(client redis.UniversalClient, channel string) *Bus {
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	return &Bus{client: client, channel: channel}
+}
+
+func (b *Bus) Publish(ctx context.Context, msg headless_cms.Invalidation) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *Bus) Subscribe(ctx context.Context, handler func(ctx context.Context, msg headless_cms.Invalidation)) error {
+	ps := b.client.Subscribe(ctx, b.channel)
+	// wait for the subscription confirmation so no message published after Subscribe returns is lost
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return err
+	}
+
+	go func() {
+		defer ps.Close()
+		ch := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				msg := headless_cms.Invalidation{}
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+					slog.ErrorContext(ctx, "redis_bus - invalid message", slog.String("payload", m.Payload), slog.Any("err", err))
+					continue
+				}
+				handler(ctx, msg)
+			}
+		}
+	}()
+	return nil
+}
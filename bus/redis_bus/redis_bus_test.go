@@ -0,0 +1,51 @@
+package redis_bus
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	useRealRedis, _ := strconv.ParseBool(os.Getenv("USE_REAL_REDIS"))
+	if !useRealRedis {
+		t.Skip("Skipping Redis tests")
+	}
+	redisAddrs := []string{os.Getenv("REDIS_ADDR")}
+	redisPassword := os.Getenv("REDIS_PASSWORD")
+	redisMasterName := os.Getenv("REDIS_MASTER_NAME")
+	redisDB := 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := New(redis.NewUniversalClient(&redis.UniversalOptions{Addrs: redisAddrs, Password: redisPassword, DB: redisDB, MasterName: redisMasterName}), "headless_cms:test")
+
+	received := make(chan headless_cms.Invalidation, 1)
+	err := b.Subscribe(ctx, func(ctx context.Context, msg headless_cms.Invalidation) {
+		received <- msg
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := headless_cms.Invalidation{Kind: headless_cms.InvalidateDel, Keys: []string{"j:published:en:login"}, Origin: "a"}
+	err = b.Publish(ctx, sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		if !reflect.DeepEqual(msg, sent) {
+			t.Error("should equal", msg, sent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("message not received")
+	}
+}
@@ -0,0 +1,244 @@
+package bolt_cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dryaf/headless_cms"
+	bolt "go.etcd.io/bbolt"
+)
+
+var _ headless_cms.Cache = &Cache{}
+
+var bucket = []byte("headless_cms")
+
+// expiryLen is the size of the unix nano expiry stored in front of every value, 0 never expires
+const expiryLen = 8
+
+// Cache stores entries in an embedded bbolt database file.
+type Cache struct {
+	path string
+	ttl  time.Duration
+
+	// lock is held exclusively only while Compact swaps the database file
+	lock sync.RWMutex
+	db   *bolt.DB
+}
+
+// New opens or creates the database at path. ttl > 0 expires entries after that duration.
+func New(path string, ttl time.Duration) (*Cache, error) {
+	// an interrupted Compact leaves the original as backup
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Rename(path+".backup", path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{path: path, ttl: ttl, db: db}, nil
+}
+
+func open(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (bc *Cache) Close() error {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	return bc.db.Close()
+}
+
+func (bc *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	var value []byte
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		stored := tx.Bucket(bucket).Get([]byte(key))
+		if stored == nil || expired(stored, time.Now()) {
+			return nil
+		}
+		// stored is only valid during the transaction
+		value = append([]byte{}, stored[expiryLen:]...)
+		return nil
+	})
+	return value, err
+}
+
+func (bc *Cache) Set(ctx context.Context, key string, value []byte) error {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	stored := make([]byte, expiryLen+len(value))
+	if bc.ttl > 0 {
+		binary.BigEndian.PutUint64(stored, uint64(time.Now().Add(bc.ttl).UnixNano()))
+	}
+	copy(stored[expiryLen:], value)
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), stored)
+	})
+}
+
+func (bc *Cache) Del(ctx context.Context, key string) error {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
+
+func (bc *Cache) Empty(ctx context.Context) error {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bucket)
+		return err
+	})
+}
+
+// DelPrefix deletes all keys starting with prefix, e.g. "j:" for all JSON entries
+// or "r:published:de:" for all rendered german pages.
+func (bc *Cache) DelPrefix(ctx context.Context, prefix string) error {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		return deleteWhile(tx.Bucket(bucket), []byte(prefix), func(k, v []byte) bool {
+			return bytes.HasPrefix(k, []byte(prefix))
+		})
+	})
+}
+
+// Compact removes expired entries and rewrites the database file to give freed pages back to the filesystem.
+func (bc *Cache) Compact(ctx context.Context) error {
+	now := time.Now()
+	bc.lock.RLock()
+	err := bc.db.Update(func(tx *bolt.Tx) error {
+		return deleteWhile(tx.Bucket(bucket), nil, func(k, v []byte) bool {
+			return expired(v, now)
+		})
+	})
+	bc.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	tmpPath := bc.path + ".compact"
+	os.Remove(tmpPath)
+	dst, err := bolt.Open(tmpPath, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	err = bolt.Compact(dst, bc.db, 64<<20)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// the original stays as backup until the compacted file is open, so a failed swap can reopen it
+	backupPath := bc.path + ".backup"
+	os.Remove(backupPath)
+	if err := bc.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return bc.reopen(err)
+	}
+	if err := os.Rename(bc.path, backupPath); err != nil {
+		os.Remove(tmpPath)
+		return bc.reopen(err)
+	}
+	if err := os.Rename(tmpPath, bc.path); err != nil {
+		os.Remove(tmpPath)
+		return bc.restore(backupPath, err)
+	}
+	db, err := open(bc.path)
+	if err != nil {
+		return bc.restore(backupPath, err)
+	}
+	bc.db = db
+	return os.Remove(backupPath)
+}
+
+// restore moves the original database back from backupPath after a failed swap and reopens it
+func (bc *Cache) restore(backupPath string, err error) error {
+	if renameErr := os.Rename(backupPath, bc.path); renameErr != nil {
+		return errors.Join(err, renameErr)
+	}
+	return bc.reopen(err)
+}
+
+// reopen opens the database at path again after Compact closed it, err is the cause
+func (bc *Cache) reopen(err error) error {
+	db, openErr := open(bc.path)
+	if openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	bc.db = db
+	return err
+}
+
+// deleteWhile deletes the entries from seek on for which match is true.
+// With a seek prefix iteration stops at the first non matching key.
+func deleteWhile(b *bolt.Bucket, seek []byte, match func(k, v []byte) bool) error {
+	// deleting while iterating can make the cursor skip keys, so collect first
+	var keys [][]byte
+	c := b.Cursor()
+	k, v := c.First()
+	if seek != nil {
+		k, v = c.Seek(seek)
+	}
+	for ; k != nil; k, v = c.Next() {
+		if !match(k, v) {
+			if seek != nil {
+				break
+			}
+			continue
+		}
+		keys = append(keys, append([]byte{}, k...))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expired(stored []byte, now time.Time) bool {
+	if len(stored) < expiryLen {
+		return true
+	}
+	expiry := int64(binary.BigEndian.Uint64(stored))
+	return expiry != 0 && now.UnixNano() > expiry
+}
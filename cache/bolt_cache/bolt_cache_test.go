@@ -0,0 +1,135 @@
+package bolt_cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dryaf/headless_cms/cache/cachetest"
+)
+
+func newCache(t *testing.T, ttl time.Duration) *Cache {
+	c, err := New(filepath.Join(t.TempDir(), "cache.db"), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestCache_Get(t *testing.T) {
+	cachetest.Run(t, newCache(t, 0))
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 50*time.Millisecond)
+	_ = c.Set(ctx, "1", []byte("1"))
+	if a1, _ := c.Get(ctx, "1"); !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("should equal")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if a1, _ := c.Get(ctx, "1"); a1 != nil {
+		t.Error("1 should have expired")
+	}
+
+	err := c.Compact(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+	_ = c.Set(ctx, "2", []byte("2"))
+	if a2, _ := c.Get(ctx, "2"); !reflect.DeepEqual(a2, []byte("2")) {
+		t.Error("cache should be usable after compaction")
+	}
+}
+
+func TestCache_DelPrefix(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 0)
+	for _, key := range []string{"i:published:en:home", "j:published:de:home", "j:published:en:about", "j:published:en:home", "r:published:en:home"} {
+		_ = c.Set(ctx, key, []byte(key))
+	}
+
+	err := c.DelPrefix(ctx, "j:published:en:")
+	if err != nil {
+		t.Error(err)
+	}
+	for key, kept := range map[string]bool{"i:published:en:home": true, "j:published:de:home": true, "j:published:en:about": false, "j:published:en:home": false, "r:published:en:home": true} {
+		value, _ := c.Get(ctx, key)
+		if kept != (value != nil) {
+			t.Error(key, "kept", value != nil)
+		}
+	}
+}
+
+func TestCache_CompactFailure(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_ = c.Set(ctx, "1", []byte("1"))
+
+	// a directory in the way of the backup makes the swap fail
+	if err := os.MkdirAll(filepath.Join(path+".backup", "in_the_way"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Compact(ctx); err == nil {
+		t.Error("compaction should fail")
+	}
+	if a1, _ := c.Get(ctx, "1"); !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("cache should serve the original after a failed compaction")
+	}
+	if err := c.Set(ctx, "2", []byte("2")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCache_CompactInterrupted(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(ctx, "1", []byte("1"))
+	c.Close()
+
+	// Compact stopped after moving the original to the backup
+	if err := os.Rename(path, path+".backup"); err != nil {
+		t.Fatal(err)
+	}
+	c, err = New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if a1, _ := c.Get(ctx, "1"); !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("the backup should be restored")
+	}
+}
+
+func TestCache_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(ctx, "1", []byte("1"))
+	c.Close()
+
+	c, err = New(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if a1, _ := c.Get(ctx, "1"); !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("1 should survive a restart")
+	}
+}
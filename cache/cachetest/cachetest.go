@@ -0,0 +1,77 @@
+// Package cachetest holds the behavior checks every headless_cms.Cache implementation has to pass.
+package cachetest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dryaf/headless_cms"
+)
+
+// Run checks Set, Get, Del and Empty on an empty cache. Misses must return a nil value,
+// an error is allowed (redis_cache reports "key not found").
+func Run(t *testing.T, c headless_cms.Cache) {
+	t.Helper()
+	ctx := context.Background()
+
+	err := c.Set(ctx, "1", []byte("1"))
+	if err != nil {
+		t.Error(err)
+	}
+	err = c.Set(ctx, "2", []byte("zwei"))
+	if err != nil {
+		t.Error(err)
+	}
+	a1, err := c.Get(ctx, "1")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("should equal")
+	}
+	a2, err := c.Get(ctx, "2")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(a2, []byte("zwei")) {
+		t.Error("should equal")
+	}
+
+	// overwrite
+	err = c.Set(ctx, "2", []byte("two"))
+	if err != nil {
+		t.Error(err)
+	}
+	a2, err = c.Get(ctx, "2")
+	if err != nil || !reflect.DeepEqual(a2, []byte("two")) {
+		t.Error("error:", err, "a2", a2)
+	}
+
+	a3, _ := c.Get(ctx, "not found")
+	if a3 != nil {
+		t.Error("a3", a3)
+	}
+
+	err = c.Del(ctx, "2")
+	if err != nil {
+		t.Error(err)
+	}
+	a2, _ = c.Get(ctx, "2")
+	if a2 != nil {
+		t.Error("a2", a2)
+	}
+	err = c.Del(ctx, "not found")
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = c.Empty(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+	a1, _ = c.Get(ctx, "1")
+	if a1 != nil {
+		t.Error("a1", a1)
+	}
+}
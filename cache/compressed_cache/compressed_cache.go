@@ -0,0 +1,162 @@
+package compressed_cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	_ headless_cms.Cache     = &Cache{}
+	_ headless_cms.TagSetter = &Cache{}
+	_ headless_cms.TagPurger = &Cache{}
+)
+
+type Codec byte
+
+const (
+	None Codec = iota
+	Gzip
+	Zstd
+	Snappy
+)
+
+// magic starts every value written by this cache, followed by one Codec byte.
+// Values without it are returned as they are, so entries written before compression was enabled stay readable.
+var magic = []byte("\x00hcz")
+
+const headerLen = 5
+
+// Cache compresses values before handing them to the wrapped cache.
+type Cache struct {
+	next      headless_cms.Cache
+	codec     Codec
+	threshold int
+
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+}
+
+// New wraps next. Values shorter than threshold bytes are stored uncompressed.
+// Every codec can be read regardless of the configured one, so the codec can be switched at any time.
+func New(next headless_cms.Cache, codec Codec, threshold int) (*Cache, error) {
+	if codec > Snappy {
+		return nil, fmt.Errorf("compressed_cache: unknown codec %d", codec)
+	}
+	zstdEncoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	zstdDecoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{next: next, codec: codec, threshold: threshold, zstdEncoder: zstdEncoder, zstdDecoder: zstdDecoder}, nil
+}
+
+func (cc *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := cc.next.Get(ctx, key)
+	if err != nil || value == nil {
+		return value, err
+	}
+	return cc.decode(value)
+}
+
+func (cc *Cache) Set(ctx context.Context, key string, value []byte) error {
+	encoded, err := cc.encode(value)
+	if err != nil {
+		return err
+	}
+	return cc.next.Set(ctx, key, encoded)
+}
+
+// SetWithTags compresses value and sets it with tags, caches without tag support get it without them.
+func (cc *Cache) SetWithTags(ctx context.Context, key string, value []byte, tags ...string) error {
+	encoded, err := cc.encode(value)
+	if err != nil {
+		return err
+	}
+	return headless_cms.SetTagged(ctx, cc.next, key, encoded, tags...)
+}
+
+// PurgeTags purges the tags from the wrapped cache, which is emptied without tag support.
+func (cc *Cache) PurgeTags(ctx context.Context, tags ...string) error {
+	return headless_cms.ApplyInvalidation(ctx, cc.next, headless_cms.Invalidation{Kind: headless_cms.InvalidateTags, Tags: tags})
+}
+
+func (cc *Cache) Del(ctx context.Context, key string) error {
+	return cc.next.Del(ctx, key)
+}
+
+func (cc *Cache) Empty(ctx context.Context) error {
+	return cc.next.Empty(ctx)
+}
+
+func (cc *Cache) encode(value []byte) ([]byte, error) {
+	codec := cc.codec
+	if len(value) < cc.threshold {
+		codec = None
+	}
+	if codec == None && !bytes.HasPrefix(value, magic) {
+		return value, nil
+	}
+
+	out := append(append(make([]byte, 0, headerLen+len(value)), magic...), byte(codec))
+	switch codec {
+	case None:
+		// raw value that happens to start with magic
+		return append(out, value...), nil
+	case Gzip:
+		buf := bytes.NewBuffer(out)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(value); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		return cc.zstdEncoder.EncodeAll(value, out), nil
+	case Snappy:
+		return append(out, snappy.Encode(nil, value)...), nil
+	}
+	return nil, fmt.Errorf("compressed_cache: unknown codec %d", codec)
+}
+
+func (cc *Cache) decode(value []byte) ([]byte, error) {
+	if len(value) < headerLen || !bytes.HasPrefix(value, magic) {
+		return value, nil
+	}
+	codec, payload := Codec(value[len(magic)]), value[headerLen:]
+	switch codec {
+	case None:
+		return payload, nil
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("compressed_cache: gzip: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case Zstd:
+		out, err := cc.zstdDecoder.DecodeAll(payload, nil)
+		if err != nil {
+			return nil, fmt.Errorf("compressed_cache: zstd: %w", err)
+		}
+		return out, nil
+	case Snappy:
+		out, err := snappy.Decode(nil, payload)
+		if err != nil {
+			return nil, fmt.Errorf("compressed_cache: snappy: %w", err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("compressed_cache: unknown codec %d", codec)
+}
@@ -0,0 +1,111 @@
+package compressed_cache
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/cachetest"
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+)
+
+func TestCache_Conformance(t *testing.T) {
+	for _, codec := range []Codec{None, Gzip, Zstd, Snappy} {
+		c, err := New(memory_cache.New(), codec, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cachetest.Run(t, c)
+	}
+}
+
+func TestCache_Codecs(t *testing.T) {
+	ctx := context.Background()
+	story := bytes.Repeat([]byte(`{"story":{"name":"home","content":{"body":[]}}}`), 100)
+
+	for _, codec := range []Codec{Gzip, Zstd, Snappy} {
+		next := memory_cache.New()
+		c, err := New(next, codec, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.Set(ctx, "big", story)
+		if err != nil {
+			t.Error(err)
+		}
+		stored, _ := next.Get(ctx, "big")
+		if len(stored) >= len(story) || Codec(stored[len(magic)]) != codec {
+			t.Error("codec", codec, "should have compressed", len(stored))
+		}
+		value, err := c.Get(ctx, "big")
+		if err != nil || !reflect.DeepEqual(value, story) {
+			t.Error("codec", codec, "roundtrip failed", err)
+		}
+
+		// below threshold stays raw
+		err = c.Set(ctx, "small", []byte(`{}`))
+		if err != nil {
+			t.Error(err)
+		}
+		stored, _ = next.Get(ctx, "small")
+		if !reflect.DeepEqual(stored, []byte(`{}`)) {
+			t.Error("small values should be stored raw")
+		}
+	}
+}
+
+func TestCache_Compatibility(t *testing.T) {
+	ctx := context.Background()
+	next := memory_cache.New()
+
+	// entries written without compression
+	_ = next.Set(ctx, "old", []byte(`{"story":{}}`))
+	// entries written with another codec
+	gz, _ := New(next, Gzip, 0)
+	_ = gz.Set(ctx, "gzip", []byte(`{"story":{}}`))
+
+	c, _ := New(next, Zstd, 0)
+	for _, key := range []string{"old", "gzip"} {
+		value, err := c.Get(ctx, key)
+		if err != nil || !reflect.DeepEqual(value, []byte(`{"story":{}}`)) {
+			t.Error(key, "should be readable", err, string(value))
+		}
+	}
+
+	// raw values looking like a header survive a roundtrip
+	raw, _ := New(next, None, 0)
+	tricky := append(append([]byte{}, magic...), byte(Gzip), 'x')
+	_ = raw.Set(ctx, "tricky", tricky)
+	value, err := raw.Get(ctx, "tricky")
+	if err != nil || !reflect.DeepEqual(value, tricky) {
+		t.Error("tricky value should roundtrip", err, value)
+	}
+}
+
+func TestCache_PurgeTags(t *testing.T) {
+	ctx := context.Background()
+	next := memory_cache.New()
+	c, err := New(next, Zstd, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.SetWithTags(ctx, "1", []byte("1"), "story:1")
+	_ = c.SetWithTags(ctx, "2", []byte("2"), "story:2")
+	if value, _ := c.Get(ctx, "1"); !reflect.DeepEqual(value, []byte("1")) {
+		t.Error("tagged values should roundtrip", value)
+	}
+
+	err = c.PurgeTags(ctx, "story:1")
+	if err != nil {
+		t.Error(err)
+	}
+	if v1, _ := next.Get(ctx, "1"); v1 != nil {
+		t.Error("1 should be purged from the wrapped cache")
+	}
+	if v2, _ := next.Get(ctx, "2"); v2 == nil {
+		t.Error("2 should still be cached")
+	}
+}
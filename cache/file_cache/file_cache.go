@@ -0,0 +1,276 @@
+package file_cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dryaf/headless_cms"
+)
+
+var _ headless_cms.Cache = &Cache{}
+
+const (
+	dataDir     = "data"
+	tmpDir      = "tmp"
+	trashPrefix = "trash-"
+
+	// longer keys are stored under their sha256 to stay below file name limits
+	maxEncodedKeyLen = 200
+
+	numPathLocks = 64
+)
+
+// Cache stores every entry as a file below dir. Writes go to a temp file that is renamed into place,
+// so readers never see partial entries and the cache survives restarts.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	// lock is held exclusively only while Empty swaps the data directory
+	lock      sync.RWMutex
+	evictLock sync.Mutex
+	size      atomic.Int64
+	// pathLocks serialize the stat and rename or remove of an entry, so concurrent writes of a key count its size once
+	pathLocks [numPathLocks]sync.Mutex
+}
+
+// New opens or creates the cache in dir. maxBytes > 0 limits the total size of all entries,
+// evicting the least recently written ones when exceeded.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	for _, d := range []string{dataDir, tmpDir} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	// leftovers of interrupted writes and purges
+	if err := clearDir(filepath.Join(dir, tmpDir)); err != nil {
+		return nil, err
+	}
+	trash, err := filepath.Glob(filepath.Join(dir, trashPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trash {
+		if err := os.RemoveAll(t); err != nil {
+			return nil, err
+		}
+	}
+
+	fc := &Cache{dir: dir, maxBytes: maxBytes}
+	entries, err := fc.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		fc.size.Add(e.size)
+	}
+	return fc, nil
+}
+
+func (fc *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	fc.lock.RLock()
+	defer fc.lock.RUnlock()
+
+	value, err := os.ReadFile(fc.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (fc *Cache) Set(ctx context.Context, key string, bytes []byte) error {
+	if fc.maxBytes > 0 && int64(len(bytes)) > fc.maxBytes {
+		return errors.New("file_cache: value exceeds size limit")
+	}
+
+	fc.lock.RLock()
+	err := fc.write(key, bytes)
+	fc.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if fc.maxBytes > 0 && fc.size.Load() > fc.maxBytes {
+		return fc.evict()
+	}
+	return nil
+}
+
+func (fc *Cache) Del(ctx context.Context, key string) error {
+	fc.lock.RLock()
+	defer fc.lock.RUnlock()
+
+	return fc.remove(fc.path(key))
+}
+
+// Empty swaps in a fresh data directory and deletes the old one afterwards,
+// so concurrent readers and writers are only blocked for the rename.
+func (fc *Cache) Empty(ctx context.Context) error {
+	trash, err := os.MkdirTemp(fc.dir, trashPrefix)
+	if err != nil {
+		return err
+	}
+
+	fc.lock.Lock()
+	err = os.Rename(filepath.Join(fc.dir, dataDir), filepath.Join(trash, dataDir))
+	if err == nil {
+		err = os.Mkdir(filepath.Join(fc.dir, dataDir), 0o755)
+		fc.size.Store(0)
+	}
+	fc.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(trash)
+}
+
+func (fc *Cache) write(key string, bytes []byte) error {
+	tmp, err := os.CreateTemp(filepath.Join(fc.dir, tmpDir), "set-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(bytes)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	path := fc.path(key)
+	mu := fc.pathLock(path)
+	mu.Lock()
+	defer mu.Unlock()
+	var oldSize int64
+	if info, err := os.Stat(path); err == nil {
+		oldSize = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	fc.size.Add(int64(len(bytes)) - oldSize)
+	return nil
+}
+
+func (fc *Cache) remove(path string) error {
+	mu := fc.pathLock(path)
+	mu.Lock()
+	defer mu.Unlock()
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fc.size.Add(-info.Size())
+	return nil
+}
+
+// evict removes the oldest entries until the cache is within its size limit again
+func (fc *Cache) evict() error {
+	fc.evictLock.Lock()
+	defer fc.evictLock.Unlock()
+	fc.lock.RLock()
+	defer fc.lock.RUnlock()
+
+	if fc.size.Load() <= fc.maxBytes {
+		return nil
+	}
+	entries, err := fc.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+	for _, e := range entries {
+		if fc.size.Load() <= fc.maxBytes {
+			break
+		}
+		if err := fc.remove(e.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (fc *Cache) entries() ([]entry, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(fc.dir, dataDir))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{path: filepath.Join(fc.dir, dataDir, de.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	return entries, nil
+}
+
+func (fc *Cache) pathLock(path string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return &fc.pathLocks[h.Sum32()%numPathLocks]
+}
+
+func (fc *Cache) path(key string) string {
+	return filepath.Join(fc.dir, dataDir, encodeKey(key))
+}
+
+// encodeKey maps any key to a file name that is safe on all platforms,
+// lowercase hex so keys do not collide on case-insensitive file systems
+func encodeKey(key string) string {
+	// the prefix keeps the empty key from mapping to an empty name
+	name := "k" + hex.EncodeToString([]byte(key))
+	if len(name) > maxEncodedKeyLen {
+		sum := sha256.Sum256([]byte(key))
+		// '~' is not part of the hex alphabet, so hashed names never collide with encoded ones
+		return "~" + hex.EncodeToString(sum[:])
+	}
+	return name
+}
+
+func clearDir(dir string) error {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, de := range dirEntries {
+		if err := os.RemoveAll(filepath.Join(dir, de.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package file_cache
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/cachetest"
+)
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Set(ctx, "j:published:en:blog/../post", []byte("1"))
+	if err != nil {
+		t.Error(err)
+	}
+	err = c.Set(ctx, "2", []byte("zwei"))
+	if err != nil {
+		t.Error(err)
+	}
+	a1, err := c.Get(ctx, "j:published:en:blog/../post")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("should equal")
+	}
+	a2, err := c.Get(ctx, "2")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(a2, []byte("zwei")) {
+		t.Error("should equal")
+	}
+	a3, err := c.Get(ctx, "not found")
+	if err != nil || a3 != nil {
+		t.Error("error:", err, "a3", a3)
+	}
+	err = c.Del(ctx, "2")
+	if err != nil {
+		t.Error(err)
+	}
+	a2, err = c.Get(ctx, "2")
+	if err != nil || a2 != nil {
+		t.Error(err)
+	}
+	err = c.Empty(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+	a1, err = c.Get(ctx, "j:published:en:blog/../post")
+	if err != nil || a1 != nil {
+		t.Error(err)
+	}
+}
+
+func TestCache_Persistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	longKey := strings.Repeat("long", 100)
+
+	c, err := New(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(ctx, longKey, []byte("long"))
+	_ = c.Set(ctx, "", []byte("empty"))
+
+	c, err = New(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.Get(ctx, longKey); !reflect.DeepEqual(v, []byte("long")) {
+		t.Error("long key should survive a restart")
+	}
+	if v, _ := c.Get(ctx, ""); !reflect.DeepEqual(v, []byte("empty")) {
+		t.Error("empty key should survive a restart")
+	}
+	if c.size.Load() != 9 {
+		t.Error("size should be restored, got", c.size.Load())
+	}
+}
+
+func TestCache_SizeLimit(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Set(ctx, "too big", []byte("01234567890"))
+	if err == nil {
+		t.Error("value above the limit should be rejected")
+	}
+
+	_ = c.Set(ctx, "1", []byte("12345"))
+	_ = c.Set(ctx, "1", []byte("1234"))
+	_ = c.Set(ctx, "2", []byte("12345"))
+	if c.size.Load() != 9 {
+		t.Error("overwrites should not count twice, got", c.size.Load())
+	}
+	_ = c.Set(ctx, "3", []byte("12345"))
+	if c.size.Load() > 10 {
+		t.Error("size should be within limit, got", c.size.Load())
+	}
+	if v, _ := c.Get(ctx, "3"); !reflect.DeepEqual(v, []byte("12345")) {
+		t.Error("newest entry should be kept")
+	}
+}
+
+func TestCache_ConcurrentSize(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_ = c.Set(ctx, "same", []byte(strconv.Itoa(i*j)))
+				if j%5 == 0 {
+					_ = c.Del(ctx, "same")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := c.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.size
+	}
+	if c.size.Load() != size {
+		t.Error("size should match the entries on disk", c.size.Load(), size)
+	}
+}
+
+func TestCache_Conformance(t *testing.T) {
+	c, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachetest.Run(t, c)
+}
+
+func TestEncodeKeyCaseInsensitive(t *testing.T) {
+	keys := []string{"j:published:en:Home", "j:published:en:home", "j:published:en:HOME", strings.Repeat("Long", 100)}
+	names := map[string]string{}
+	for _, key := range keys {
+		name := encodeKey(key)
+		if name != strings.ToLower(name) {
+			t.Error("name not lowercase:", name)
+		}
+		if other, ok := names[strings.ToLower(name)]; ok {
+			t.Error("keys collide on case-insensitive file systems:", key, other)
+		}
+		names[strings.ToLower(name)] = key
+	}
+}
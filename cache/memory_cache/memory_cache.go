@@ -1,6 +1,7 @@
 package memory_cache
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"sync"
@@ -9,19 +10,52 @@ import (
 )
 
 var _ headless_cms.Cache = &Cache{}
+var _ headless_cms.TagPurger = &Cache{}
+var _ headless_cms.TagSetter = &Cache{}
 
 type Cache struct {
 	mp   map[string]interface{}
 	lock sync.RWMutex
+
+	// tags holds the keys by tag and keyTags the tags by key, for entries set with SetWithTags
+	tags    map[string]map[string]struct{}
+	keyTags map[string][]string
+
+	// maxEntries > 0 bounds the cache, evicting the least recently used key
+	maxEntries int
+	lru        *list.List
+	lruElems   map[string]*list.Element
 }
 
 func New() *Cache {
 	return &Cache{mp: make(map[string]interface{})}
 }
 
+// NewWithLimit returns a cache holding at most maxEntries keys, evicting the least recently used.
+func NewWithLimit(maxEntries int) *Cache {
+	if maxEntries <= 0 {
+		return New()
+	}
+	return &Cache{
+		mp:         make(map[string]interface{}),
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		lruElems:   make(map[string]*list.Element),
+	}
+}
+
 func (mc *Cache) Get(ctx context.Context, key string) ([]byte, error) {
-	mc.lock.RLock()
-	defer mc.lock.RUnlock()
+	if mc.maxEntries > 0 {
+		// recency bookkeeping needs the write lock
+		mc.lock.Lock()
+		defer mc.lock.Unlock()
+		if elem, ok := mc.lruElems[key]; ok {
+			mc.lru.MoveToFront(elem)
+		}
+	} else {
+		mc.lock.RLock()
+		defer mc.lock.RUnlock()
+	}
 
 	value, ok := mc.mp[key]
 	if !ok {
@@ -35,10 +69,41 @@ func (mc *Cache) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 func (mc *Cache) Set(ctx context.Context, key string, obj []byte) error {
+	return mc.SetWithTags(ctx, key, obj)
+}
+
+// SetWithTags sets key and replaces its tags, PurgeTags deletes it with any of them.
+func (mc *Cache) SetWithTags(ctx context.Context, key string, obj []byte, tags ...string) error {
 	mc.lock.Lock()
 	defer mc.lock.Unlock()
 
 	mc.mp[key] = obj
+	mc.untag(key)
+	for _, tag := range tags {
+		if mc.tags == nil {
+			mc.tags = make(map[string]map[string]struct{})
+			mc.keyTags = make(map[string][]string)
+		}
+		if mc.tags[tag] == nil {
+			mc.tags[tag] = make(map[string]struct{})
+		}
+		mc.tags[tag][key] = struct{}{}
+		mc.keyTags[key] = append(mc.keyTags[key], tag)
+	}
+	if mc.maxEntries > 0 {
+		if elem, ok := mc.lruElems[key]; ok {
+			mc.lru.MoveToFront(elem)
+		} else {
+			mc.lruElems[key] = mc.lru.PushFront(key)
+		}
+		for mc.lru.Len() > mc.maxEntries {
+			oldest := mc.lru.Back()
+			mc.lru.Remove(oldest)
+			delete(mc.lruElems, oldest.Value.(string))
+			delete(mc.mp, oldest.Value.(string))
+			mc.untag(oldest.Value.(string))
+		}
+	}
 	return nil
 }
 
@@ -46,14 +111,56 @@ func (mc *Cache) Del(ctx context.Context, key string) error {
 	mc.lock.Lock()
 	defer mc.lock.Unlock()
 
-	delete(mc.mp, key)
+	mc.del(key)
 	return nil
 }
 
+// PurgeTags deletes the keys set with any of the tags.
+func (mc *Cache) PurgeTags(ctx context.Context, tags ...string) error {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	for _, tag := range tags {
+		for key := range mc.tags[tag] {
+			mc.del(key)
+		}
+	}
+	return nil
+}
+
+// del deletes key, the caller holds the write lock
+func (mc *Cache) del(key string) {
+	delete(mc.mp, key)
+	mc.untag(key)
+	if mc.maxEntries > 0 {
+		if elem, ok := mc.lruElems[key]; ok {
+			mc.lru.Remove(elem)
+			delete(mc.lruElems, key)
+		}
+	}
+}
+
+// untag removes key from its tags, the caller holds the write lock
+func (mc *Cache) untag(key string) {
+	for _, tag := range mc.keyTags[key] {
+		delete(mc.tags[tag], key)
+		if len(mc.tags[tag]) == 0 {
+			delete(mc.tags, tag)
+		}
+	}
+	delete(mc.keyTags, key)
+}
+
 func (mc *Cache) Empty(ctx context.Context) error {
 	mc.lock.Lock()
 	defer mc.lock.Unlock()
 
 	mc.mp = make(map[string]interface{})
+	mc.tags = nil
+	mc.keyTags = nil
+	if mc.maxEntries > 0 {
+		mc.lru.Init()
+		mc.lruElems = make(map[string]*list.Element)
+	}
 	return nil
 }
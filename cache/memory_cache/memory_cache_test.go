@@ -4,6 +4,8 @@ import (
 	"context"
 	"reflect"
 	"testing"
+
+	"github.com/dryaf/headless_cms/cache/cachetest"
 )
 
 func TestCache_Get(t *testing.T) {
@@ -52,3 +54,64 @@ func TestCache_Get(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestCache_WithLimit(t *testing.T) {
+	ctx := context.Background()
+	c := NewWithLimit(2)
+	_ = c.Set(ctx, "1", []byte("1"))
+	_ = c.Set(ctx, "2", []byte("2"))
+	// touch 1 so 2 becomes the least recently used
+	if a1, _ := c.Get(ctx, "1"); a1 == nil {
+		t.Error("1 should be cached")
+	}
+	_ = c.Set(ctx, "3", []byte("3"))
+
+	if a2, _ := c.Get(ctx, "2"); a2 != nil {
+		t.Error("2 should have been evicted")
+	}
+	if a1, _ := c.Get(ctx, "1"); !reflect.DeepEqual(a1, []byte("1")) {
+		t.Error("1 should still be cached")
+	}
+	if a3, _ := c.Get(ctx, "3"); !reflect.DeepEqual(a3, []byte("3")) {
+		t.Error("3 should be cached")
+	}
+
+	_ = c.Del(ctx, "1")
+	_ = c.Empty(ctx)
+	_ = c.Set(ctx, "4", []byte("4"))
+	if a4, _ := c.Get(ctx, "4"); !reflect.DeepEqual(a4, []byte("4")) {
+		t.Error("4 should be cached")
+	}
+}
+
+func TestCache_PurgeTags(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []*Cache{New(), NewWithLimit(10)} {
+		_ = c.SetWithTags(ctx, "home", []byte("home"), "story:1", "tag:news")
+		_ = c.SetWithTags(ctx, "about", []byte("about"), "story:2")
+		_ = c.SetWithTags(ctx, "blog", []byte("blog"), "tag:news")
+		// a plain Set replaces the tags
+		_ = c.Set(ctx, "blog", []byte("blog"))
+
+		if err := c.PurgeTags(ctx, "tag:news", "unknown"); err != nil {
+			t.Error(err)
+		}
+		if a, _ := c.Get(ctx, "home"); a != nil {
+			t.Error("home should have been purged")
+		}
+		if a, _ := c.Get(ctx, "about"); a == nil {
+			t.Error("about should still be cached")
+		}
+		if a, _ := c.Get(ctx, "blog"); a == nil {
+			t.Error("blog should still be cached")
+		}
+		if len(c.tags) != 1 || len(c.keyTags) != 1 {
+			t.Error("tags of purged keys should be removed:", c.tags, c.keyTags)
+		}
+	}
+}
+
+func TestCache_Conformance(t *testing.T) {
+	cachetest.Run(t, New())
+	cachetest.Run(t, NewWithLimit(10))
+}
@@ -6,17 +6,24 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/cache/cachetest"
 	"github.com/redis/go-redis/v9"
 )
 
-func TestCache_Get(t *testing.T) {
+// newTestCache connects to the redis of REDIS_ADDR, REDIS_PASSWORD and REDIS_MASTER_NAME
+func newTestCache() headless_cms.Cache {
 	redisAddrs := []string{os.Getenv("REDIS_ADDR")}
 	redisPassword := os.Getenv("REDIS_PASSWORD")
 	redisMasterName := os.Getenv("REDIS_MASTER_NAME")
 	redisDB := 0
 
+	return New(redis.NewUniversalClient(&redis.UniversalOptions{Addrs: redisAddrs, Password: redisPassword, DB: redisDB, MasterName: redisMasterName}))
+}
+
+func TestCache_Get(t *testing.T) {
 	ctx := context.Background()
-	c := New(redis.NewUniversalClient(&redis.UniversalOptions{Addrs: redisAddrs, Password: redisPassword, DB: redisDB, MasterName: redisMasterName}))
+	c := newTestCache()
 
 	// Set key-value pairs in the cache
 	err := c.Set(ctx, "1", []byte("1"))
@@ -70,3 +77,11 @@ func TestCache_Get(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestCache_Conformance(t *testing.T) {
+	c := newTestCache()
+	if err := c.Empty(context.Background()); err != nil {
+		t.Error(err)
+	}
+	cachetest.Run(t, c)
+}
@@ -0,0 +1,132 @@
+package tiered_cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+)
+
+var _ headless_cms.Cache = &Cache{}
+var _ headless_cms.TagPurger = &Cache{}
+var _ headless_cms.TagSetter = &Cache{}
+
+// Cache layers a local in-process cache (e.g. memory_cache.NewWithLimit) over a shared remote cache (e.g. redis_cache).
+// Reads are served from the local tier first and fill it on remote hits.
+// Del and Empty go to both tiers and, when a bus is given, to the local tiers of the other instances.
+// Local entries filled from the remote tier carry no tags, so tag purges empty the local tiers.
+type Cache struct {
+	local  headless_cms.Cache
+	remote headless_cms.Cache
+	bus    headless_cms.InvalidationBus
+	origin string
+}
+
+// New subscribes to bus for the lifetime of ctx. bus may be nil for a single instance.
+func New(ctx context.Context, local headless_cms.Cache, remote headless_cms.Cache, bus headless_cms.InvalidationBus) (*Cache, error) {
+	if local == nil || remote == nil {
+		return nil, errors.New("tiered_cache: local and remote cache are required")
+	}
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, err
+	}
+	tc := &Cache{local: local, remote: remote, bus: bus, origin: hex.EncodeToString(origin)}
+	if bus != nil {
+		if err := bus.Subscribe(ctx, tc.apply); err != nil {
+			return nil, err
+		}
+	}
+	return tc, nil
+}
+
+func (tc *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := tc.local.Get(ctx, key)
+	if err == nil && value != nil {
+		return value, nil
+	}
+
+	value, err = tc.remote.Get(ctx, key)
+	if err != nil || value == nil {
+		return value, err
+	}
+	if err := tc.local.Set(ctx, key, value); err != nil {
+		slog.WarnContext(ctx, "tiered_cache - local.Set", slog.String("key", key), slog.Any("err", err))
+	}
+	return value, nil
+}
+
+func (tc *Cache) Set(ctx context.Context, key string, bytes []byte) error {
+	if err := tc.remote.Set(ctx, key, bytes); err != nil {
+		return err
+	}
+	return tc.local.Set(ctx, key, bytes)
+}
+
+// SetWithTags sets key with tags in both tiers, tiers without tag support get it without them.
+func (tc *Cache) SetWithTags(ctx context.Context, key string, bytes []byte, tags ...string) error {
+	if err := headless_cms.SetTagged(ctx, tc.remote, key, bytes, tags...); err != nil {
+		return err
+	}
+	return headless_cms.SetTagged(ctx, tc.local, key, bytes, tags...)
+}
+
+func (tc *Cache) Del(ctx context.Context, key string) error {
+	if err := tc.remote.Del(ctx, key); err != nil {
+		return err
+	}
+	if err := tc.local.Del(ctx, key); err != nil {
+		return err
+	}
+	return tc.publish(ctx, headless_cms.Invalidation{Kind: headless_cms.InvalidateDel, Keys: []string{key}})
+}
+
+func (tc *Cache) Empty(ctx context.Context) error {
+	if err := tc.remote.Empty(ctx); err != nil {
+		return err
+	}
+	if err := tc.local.Empty(ctx); err != nil {
+		return err
+	}
+	return tc.publish(ctx, headless_cms.Invalidation{Kind: headless_cms.InvalidateEmpty})
+}
+
+// PurgeTags purges the tags from the remote tier, which is emptied without tag support, and empties the local tier.
+func (tc *Cache) PurgeTags(ctx context.Context, tags ...string) error {
+	msg := headless_cms.Invalidation{Kind: headless_cms.InvalidateTags, Tags: tags}
+	if err := headless_cms.ApplyInvalidation(ctx, tc.remote, msg); err != nil {
+		return err
+	}
+	if err := tc.local.Empty(ctx); err != nil {
+		return err
+	}
+	return tc.publish(ctx, msg)
+}
+
+func (tc *Cache) publish(ctx context.Context, msg headless_cms.Invalidation) error {
+	if tc.bus == nil {
+		return nil
+	}
+	msg.Origin = tc.origin
+	return tc.bus.Publish(ctx, msg)
+}
+
+// apply drops entries invalidated by another instance from the local tier only, the remote tier is shared.
+func (tc *Cache) apply(ctx context.Context, msg headless_cms.Invalidation) {
+	if msg.Origin == tc.origin {
+		return
+	}
+	var err error
+	if msg.Kind == headless_cms.InvalidateTags {
+		err = tc.local.Empty(ctx)
+	} else {
+		err = headless_cms.ApplyInvalidation(ctx, tc.local, msg)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "tiered_cache - apply invalidation", slog.Any("msg", msg), slog.Any("err", err))
+	}
+}
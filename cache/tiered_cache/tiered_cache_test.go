@@ -0,0 +1,160 @@
+package tiered_cache
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+)
+
+// syncBus delivers every message to all subscribers synchronously
+type syncBus struct {
+	lock     sync.Mutex
+	handlers []func(context.Context, headless_cms.Invalidation)
+}
+
+func (b *syncBus) Publish(ctx context.Context, msg headless_cms.Invalidation) error {
+	b.lock.Lock()
+	handlers := append([]func(context.Context, headless_cms.Invalidation){}, b.handlers...)
+	b.lock.Unlock()
+	for _, h := range handlers {
+		h(ctx, msg)
+	}
+	return nil
+}
+
+func (b *syncBus) Subscribe(ctx context.Context, handler func(context.Context, headless_cms.Invalidation)) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+	local := memory_cache.New()
+	remote := memory_cache.New()
+	c, err := New(ctx, local, remote, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Set(ctx, "1", []byte("1"))
+	if err != nil {
+		t.Error(err)
+	}
+	if r1, _ := remote.Get(ctx, "1"); !reflect.DeepEqual(r1, []byte("1")) {
+		t.Error("remote should contain 1")
+	}
+
+	// remote hit fills the local tier
+	_ = remote.Set(ctx, "2", []byte("zwei"))
+	a2, err := c.Get(ctx, "2")
+	if err != nil || !reflect.DeepEqual(a2, []byte("zwei")) {
+		t.Error("error:", err, "a2", a2)
+	}
+	if l2, _ := local.Get(ctx, "2"); !reflect.DeepEqual(l2, []byte("zwei")) {
+		t.Error("local should have been filled")
+	}
+
+	a3, err := c.Get(ctx, "not found")
+	if err != nil || a3 != nil {
+		t.Error("error:", err, "a3", a3)
+	}
+
+	err = c.Del(ctx, "2")
+	if err != nil {
+		t.Error(err)
+	}
+	l2, _ := local.Get(ctx, "2")
+	r2, _ := remote.Get(ctx, "2")
+	if l2 != nil || r2 != nil {
+		t.Error("2 should be deleted from both tiers")
+	}
+
+	err = c.Empty(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+	a1, err := c.Get(ctx, "1")
+	if err != nil || a1 != nil {
+		t.Error(err)
+	}
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	bus := &syncBus{}
+	remote := memory_cache.New()
+	localA := memory_cache.New()
+	localB := memory_cache.New()
+	a, err := New(ctx, localA, remote, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(ctx, localB, remote, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = a.Set(ctx, "1", []byte("1"))
+	_ = a.Set(ctx, "2", []byte("2"))
+	_, _ = b.Get(ctx, "1")
+	_, _ = b.Get(ctx, "2")
+
+	err = a.Del(ctx, "1")
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, _ := localB.Get(ctx, "1"); l1 != nil {
+		t.Error("del should reach the other instance")
+	}
+	if l2, _ := localB.Get(ctx, "2"); l2 == nil {
+		t.Error("2 should still be cached on the other instance")
+	}
+
+	err = a.Empty(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+	if l2, _ := localB.Get(ctx, "2"); l2 != nil {
+		t.Error("empty should reach the other instance")
+	}
+}
+
+func TestCache_PurgeTags(t *testing.T) {
+	ctx := context.Background()
+	bus := &syncBus{}
+	remote := memory_cache.New()
+	localA := memory_cache.New()
+	localB := memory_cache.New()
+	a, err := New(ctx, localA, remote, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(ctx, localB, remote, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = a.SetWithTags(ctx, "1", []byte("1"), "story:1")
+	_ = a.SetWithTags(ctx, "2", []byte("2"), "story:2")
+	_, _ = b.Get(ctx, "1")
+
+	err = a.PurgeTags(ctx, "story:1")
+	if err != nil {
+		t.Error(err)
+	}
+	if r1, _ := remote.Get(ctx, "1"); r1 != nil {
+		t.Error("1 should be purged from the remote tier")
+	}
+	if r2, _ := remote.Get(ctx, "2"); r2 == nil {
+		t.Error("2 should still be cached in the remote tier")
+	}
+	if l1, _ := localB.Get(ctx, "1"); l1 != nil {
+		t.Error("the purge should reach the untagged local tier of the other instance")
+	}
+}
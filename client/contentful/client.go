@@ -0,0 +1,283 @@
+package contentful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/internal/restclient"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var _ headless_cms.Client = &Client{}
+
+// Client reads entries of one content type from the Contentful Content Delivery API.
+// The "draft" version is read from the Preview API and never cached.
+type Client struct {
+	HttpClient HTTPClient
+
+	// DeliveryAPIURL and PreviewAPIURL default to the public Contentful hosts
+	DeliveryAPIURL string
+	PreviewAPIURL  string
+	Environment    string // "master"
+	ContentType    string // "page"
+	SlugField      string // "slug"
+	// BodyField holds the linked entries indexed by GetPageAsSimpleBlocksWithID
+	BodyField string // "body"
+	// Include is the depth up to which linked entries and assets are resolved, max 10
+	Include int // 2
+	// Locales maps the language argument to Contentful locales, e.g. "en" to "en-US". Unmapped languages are passed as they are.
+	Locales map[string]string
+
+	cache                 headless_cms.Cache
+	cacheEmptyActionToken string
+
+	spaceID      string
+	cmsAuthToken string
+	previewToken string
+
+	versionDefault           string // "published"
+	versionWhereCacheIgnored string // "draft"
+}
+
+func NewClient(ctx context.Context, spaceID string, token string, previewToken string, emptyCacheToken string, cache headless_cms.Cache, httpClient HTTPClient) *Client {
+	if spaceID == "" {
+		slog.ErrorContext(ctx, "contentful - SpaceID is empty")
+		os.Exit(1)
+	}
+	if token == "" {
+		slog.ErrorContext(ctx, "contentful - AuthToken is empty")
+		os.Exit(1)
+	}
+	if emptyCacheToken == "" {
+		slog.ErrorContext(ctx, "contentful - EmptyCacheToken is empty")
+		os.Exit(1)
+	}
+	if cache == nil {
+		slog.ErrorContext(ctx, "contentful - Cache is nil")
+		os.Exit(1)
+	}
+	return &Client{
+		cache:                    cache,
+		cacheEmptyActionToken:    emptyCacheToken,
+		versionWhereCacheIgnored: "draft",
+
+		HttpClient:     httpClient,
+		DeliveryAPIURL: "https://cdn.contentful.com",
+		PreviewAPIURL:  "https://preview.contentful.com",
+		Environment:    "master",
+		ContentType:    "page",
+		SlugField:      "slug",
+		BodyField:      "body",
+		Include:        2,
+		Locales:        map[string]string{},
+
+		spaceID:        spaceID,
+		cmsAuthToken:   token,
+		previewToken:   previewToken,
+		versionDefault: "published",
+	}
+}
+
+func (c *Client) AuthToken() string {
+	return c.cmsAuthToken
+}
+
+func (c *Client) Cache() headless_cms.Cache {
+	return c.cache
+}
+
+func (c *Client) EmptyCache(ctx context.Context, token string) error {
+	if token != c.cacheEmptyActionToken {
+		return errors.New("token incorrect")
+	}
+	return c.cache.Empty(ctx)
+}
+
+func (c *Client) EmptyCacheToken(ctx context.Context) (string, error) {
+	if c.cacheEmptyActionToken == "" {
+		return "", errors.New("token not set")
+	}
+	return c.cacheEmptyActionToken, nil
+}
+
+// GetPageAsJSON returns the raw entries response for the entry with the slug, or "" for all entries of the content type
+func (c *Client) GetPageAsJSON(ctx context.Context, page string, version string, language string) ([]byte, error) {
+	return restclient.Bytes(ctx, c.cache, "contentful", c.CacheKey("j", page, version, language), version != c.versionWhereCacheIgnored, func() ([]byte, error) {
+		reqURL, token := c.cmsURL(page, version, language)
+		return restclient.Get(ctx, c.HttpClient, reqURL, token, contentfulStatusDescriptions)
+	})
+}
+
+// GetPage returns {"entry": entry} for a slug and {"entries": [entry, ...]} for "", with links resolved
+func (c *Client) GetPage(ctx context.Context, page string, version string, language string) (map[string]any, error) {
+	cacheKey := c.CacheKey("r", page, version, language)
+	return restclient.JSON(ctx, c.cache, "contentful", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]any, error) {
+		entries, err := c.resolvedEntries(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+		if page == "" {
+			return map[string]any{"entries": entries}, nil
+		}
+		return map[string]any{"entry": entries[0]}, nil
+	})
+}
+
+// GetPageAsSimpleBlocksWithID indexes the fields of the entries linked in BodyField by their "id" field
+func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, version string, language string) (map[string]map[string]any, error) {
+	cacheKey := c.CacheKey("i", page, version, language)
+	return restclient.JSON(ctx, c.cache, "contentful", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]map[string]any, error) {
+		entries, err := c.resolvedEntries(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+
+		resp := map[string]map[string]any{}
+		fields, _ := entries[0]["fields"].(map[string]any)
+		blocks, _ := fields[c.BodyField].([]any)
+		for _, b := range blocks {
+			block, _ := b.(map[string]any)
+			blockFields, ok := block["fields"].(map[string]any)
+			if !ok {
+				continue
+			}
+			id, ok := blockFields["id"].(string)
+			if ok && len(id) > 0 {
+				resp[id] = blockFields
+			}
+		}
+		return resp, nil
+	})
+}
+
+func (c *Client) CacheKey(prefix, page, version, language string) string {
+	return fmt.Sprint(prefix, ":", version, ":", language, ":", page)
+}
+
+// resolvedEntries returns the entries of the response with all included links replaced, at least one
+func (c *Client) resolvedEntries(ctx context.Context, page string, version string, language string) ([]map[string]any, error) {
+	jsonResp, err := c.GetPageAsJSON(ctx, page, version, language)
+	if err != nil {
+		return nil, fmt.Errorf("request_json: %w", err)
+	}
+	cmsData := entriesResponse{}
+	err = json.Unmarshal(jsonResp, &cmsData)
+	if err != nil {
+		return nil, fmt.Errorf("json_unmarshal: %w", err)
+	}
+	if len(cmsData.Items) == 0 {
+		return nil, fmt.Errorf("status: %d err: %w", http.StatusNotFound, errors.New(contentfulStatusDescriptions[http.StatusNotFound]))
+	}
+
+	links := map[string]map[string]any{}
+	for _, e := range cmsData.Includes.Entry {
+		links[linkKey("Entry", e)] = e
+	}
+	for _, a := range cmsData.Includes.Asset {
+		links[linkKey("Asset", a)] = a
+	}
+	// items can link each other
+	for _, e := range cmsData.Items {
+		links[linkKey("Entry", e)] = e
+	}
+
+	entries := make([]map[string]any, 0, len(cmsData.Items))
+	for _, item := range cmsData.Items {
+		resolved, _ := resolveLinks(item, links, c.Include).(map[string]any)
+		entries = append(entries, resolved)
+	}
+	return entries, nil
+}
+
+// resolveLinks copies node replacing link objects with the linked entry or asset up to depth levels.
+// Unresolvable links, e.g. to unpublished entries, are kept as they are.
+func resolveLinks(node any, links map[string]map[string]any, depth int) any {
+	switch n := node.(type) {
+	case map[string]any:
+		if sys, ok := n["sys"].(map[string]any); ok && sys["type"] == "Link" {
+			linkType, _ := sys["linkType"].(string)
+			target, found := links[linkKey(linkType, n)]
+			if !found || depth <= 0 {
+				return n
+			}
+			return resolveLinks(target, links, depth-1)
+		}
+		out := make(map[string]any, len(n))
+		for k, v := range n {
+			out[k] = resolveLinks(v, links, depth)
+		}
+		return out
+	case []any:
+		out := make([]any, len(n))
+		for i, v := range n {
+			out[i] = resolveLinks(v, links, depth)
+		}
+		return out
+	}
+	return node
+}
+
+func linkKey(linkType string, obj map[string]any) string {
+	sys, _ := obj["sys"].(map[string]any)
+	id, _ := sys["id"].(string)
+	return linkType + ":" + id
+}
+
+func (c *Client) cmsURL(page, version, language string) (string, string) {
+	if version == "" {
+		version = c.versionDefault
+	}
+	baseURL, token := c.DeliveryAPIURL, c.cmsAuthToken
+	if version == c.versionWhereCacheIgnored {
+		baseURL, token = c.PreviewAPIURL, c.previewToken
+	}
+
+	params := url.Values{}
+	params.Set("content_type", c.ContentType)
+	params.Set("include", strconv.Itoa(c.Include))
+	if page != "" {
+		params.Set("fields."+c.SlugField, page)
+	}
+	if language != "" {
+		locale, ok := c.Locales[language]
+		if !ok {
+			locale = language
+		}
+		params.Set("locale", locale)
+	}
+	return baseURL + "/spaces/" + url.PathEscape(c.spaceID) + "/environments/" + url.PathEscape(c.Environment) + "/entries?" + params.Encode(), token
+}
+
+// https://www.contentful.com/developers/docs/references/errors/
+var contentfulStatusDescriptions = map[int]string{
+	200: "OK Everything worked as expected.",
+	400: "Bad Request The request was malformed or missing a required parameter.",
+	401: "Unauthorized The access token is invalid or missing.",
+	403: "Access Denied The access token has no access to the space or environment.",
+	404: "Not Found The requested resource or endpoint could not be found.",
+	429: "Too Many Requests Rate limit exceeded, retry after the time in the X-Contentful-RateLimit-Reset header.",
+	500: "Server Error Something went wrong on Contentful's end.",
+	502: "Bad Gateway The environment could not be reached.",
+	503: "Service Unavailable Contentful is temporarily unavailable.",
+}
+
+type entriesResponse struct {
+	Total    int              `json:"total"`
+	Items    []map[string]any `json:"items"`
+	Includes struct {
+		Entry []map[string]any `json:"Entry"`
+		Asset []map[string]any `json:"Asset"`
+	} `json:"includes"`
+}
@@ -0,0 +1,157 @@
+package contentful_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/contentful"
+	"github.com/dryaf/headless_cms/client/internal/clienttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const entriesResponse = `{
+	"total": 1,
+	"items": [{
+		"sys": {"id": "page1", "type": "Entry"},
+		"fields": {
+			"slug": "login",
+			"title": "Login",
+			"image": {"sys": {"type": "Link", "linkType": "Asset", "id": "asset1"}},
+			"body": [
+				{"sys": {"type": "Link", "linkType": "Entry", "id": "text1"}},
+				{"sys": {"type": "Link", "linkType": "Entry", "id": "missing"}}
+			]
+		}
+	}],
+	"includes": {
+		"Entry": [{
+			"sys": {"id": "text1", "type": "Entry"},
+			"fields": {"id": "headline", "text": "Welcome"}
+		}],
+		"Asset": [{
+			"sys": {"id": "asset1", "type": "Asset"},
+			"fields": {"file": {"url": "//images.ctfassets.net/logo.png"}}
+		}]
+	}
+}`
+
+func newTestServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	return clienttest.NewServer(t, requests, func(r *http.Request) string {
+		if r.URL.Query().Get("fields.slug") == "unknown" {
+			return `{"total": 0, "items": []}`
+		}
+		return entriesResponse
+	}, "delivery_token", "preview_token")
+}
+
+func newTestClient(server *httptest.Server) *contentful.Client {
+	client := contentful.NewClient(context.Background(), "space1", "delivery_token", "preview_token", "empty_cache_token", memory_cache.New(), server.Client())
+	client.DeliveryAPIURL = server.URL + "/delivery"
+	client.PreviewAPIURL = server.URL + "/preview"
+	client.Locales = map[string]string{"en": "en-US"}
+	return client
+}
+
+func TestGetPageAsJSON(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.JSONEq(t, entriesResponse, string(resp))
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/delivery/spaces/space1/environments/master/entries", requests[0].URL.Path)
+	assert.Equal(t, "page", requests[0].URL.Query().Get("content_type"))
+	assert.Equal(t, "login", requests[0].URL.Query().Get("fields.slug"))
+	assert.Equal(t, "en-US", requests[0].URL.Query().Get("locale"))
+	assert.Equal(t, "2", requests[0].URL.Query().Get("include"))
+
+	// cached
+	_, err = client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// draft bypasses the cache and uses the preview API
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "de")
+	require.NoError(t, err)
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "de")
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "/preview/spaces/space1/environments/master/entries", requests[2].URL.Path)
+	assert.Equal(t, "Bearer preview_token", requests[2].Header.Get("Authorization"))
+	assert.Equal(t, "de", requests[2].URL.Query().Get("locale"))
+}
+
+func TestGetPage(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPage(ctx, "login", "published", "en")
+	require.NoError(t, err)
+
+	entry := resp["entry"].(map[string]any)
+	fields := entry["fields"].(map[string]any)
+	assert.Equal(t, "Login", fields["title"])
+	image := fields["image"].(map[string]any)
+	assert.Equal(t, "//images.ctfassets.net/logo.png", image["fields"].(map[string]any)["file"].(map[string]any)["url"])
+	body := fields["body"].([]any)
+	assert.Equal(t, "Welcome", body[0].(map[string]any)["fields"].(map[string]any)["text"])
+	// unresolvable links stay links
+	assert.Equal(t, "Link", body[1].(map[string]any)["sys"].(map[string]any)["type"])
+
+	// cached
+	cached, err := client.GetPage(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, resp, cached)
+	assert.Len(t, requests, 1)
+
+	_, err = client.GetPage(ctx, "unknown", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestGetPageAsSimpleBlocksWithID(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPageAsSimpleBlocksWithID(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]any{"headline": {"id": "headline", "text": "Welcome"}}, resp)
+
+	cached, err := client.GetPageAsSimpleBlocksWithID(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, resp, cached)
+	assert.Len(t, requests, 1)
+}
+
+func TestErrorStatus(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := newTestServer(t, &requests)
+	client := contentful.NewClient(ctx, "space1", "wrong_token", "", "empty_cache_token", memory_cache.New(), server.Client())
+	client.DeliveryAPIURL = server.URL
+
+	_, err := client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestEmptyCache(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	assert.Error(t, client.EmptyCache(ctx, "wrong_token"))
+	assert.NoError(t, client.EmptyCache(ctx, "empty_cache_token"))
+	token, err := client.EmptyCacheToken(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "empty_cache_token", token)
+}
@@ -0,0 +1,314 @@
+package directus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/internal/restclient"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var _ headless_cms.Client = &Client{}
+
+// Client reads items of one collection from the Directus items REST API with a static token.
+// The "draft" version drops the StatusField filter and is never cached.
+type Client struct {
+	HttpClient HTTPClient
+
+	Collection string // "pages"
+	SlugField  string // "slug"
+	// StatusField is filtered to "published" unless the draft version is requested, "" disables the filter
+	StatusField string // "status"
+	// Fields selects the returned fields including nested relations
+	Fields []string // "*", "translations.*"
+	// Deep is merged into the deep parameter, e.g. {"blocks": {"_limit": 50}}
+	Deep map[string]any
+
+	// TranslationsField is the translations junction of an item, its entries are matched on LanguageField.
+	// The matching translation is merged into the item so templates don't need to know about the junction.
+	TranslationsField string // "translations"
+	LanguageField     string // "languages_code"
+	// Languages maps the language argument to Directus language codes, e.g. "de" to "de-DE". Unmapped languages are passed as they are.
+	Languages map[string]string
+
+	// BodyField is indexed by GetPageAsSimpleBlocksWithID, items of many-to-any builders are unwrapped.
+	// Items carry a numeric Directus "id", so blocks are indexed by the text field BlockIDField.
+	BodyField    string // "blocks"
+	BlockIDField string // "block_id"
+
+	cache                 headless_cms.Cache
+	cacheEmptyActionToken string
+
+	cmsAuthToken string
+	cmsAPIUrl    string
+
+	versionDefault           string // "published"
+	versionWhereCacheIgnored string // "draft"
+}
+
+// NewClient for the Directus instance at apiURL, e.g. "https://cms.example.com"
+func NewClient(ctx context.Context, apiURL string, token string, emptyCacheToken string, cache headless_cms.Cache, httpClient HTTPClient) *Client {
+	if apiURL == "" {
+		slog.ErrorContext(ctx, "directus - APIUrl is empty")
+		os.Exit(1)
+	}
+	if token == "" {
+		slog.ErrorContext(ctx, "directus - AuthToken is empty")
+		os.Exit(1)
+	}
+	if emptyCacheToken == "" {
+		slog.ErrorContext(ctx, "directus - EmptyCacheToken is empty")
+		os.Exit(1)
+	}
+	if cache == nil {
+		slog.ErrorContext(ctx, "directus - Cache is nil")
+		os.Exit(1)
+	}
+	return &Client{
+		cache:                    cache,
+		cacheEmptyActionToken:    emptyCacheToken,
+		versionWhereCacheIgnored: "draft",
+
+		HttpClient:        httpClient,
+		Collection:        "pages",
+		SlugField:         "slug",
+		StatusField:       "status",
+		Fields:            []string{"*", "translations.*"},
+		Deep:              map[string]any{},
+		TranslationsField: "translations",
+		LanguageField:     "languages_code",
+		Languages:         map[string]string{},
+		BodyField:         "blocks",
+		BlockIDField:      "block_id",
+
+		cmsAPIUrl:      strings.TrimSuffix(apiURL, "/") + "/items/",
+		cmsAuthToken:   token,
+		versionDefault: "published",
+	}
+}
+
+func (c *Client) AuthToken() string {
+	return c.cmsAuthToken
+}
+
+func (c *Client) Cache() headless_cms.Cache {
+	return c.cache
+}
+
+func (c *Client) EmptyCache(ctx context.Context, token string) error {
+	if token != c.cacheEmptyActionToken {
+		return errors.New("token incorrect")
+	}
+	return c.cache.Empty(ctx)
+}
+
+func (c *Client) EmptyCacheToken(ctx context.Context) (string, error) {
+	if c.cacheEmptyActionToken == "" {
+		return "", errors.New("token not set")
+	}
+	return c.cacheEmptyActionToken, nil
+}
+
+// GetPageAsJSON returns the raw items response for the item with the slug, or "" for all items
+func (c *Client) GetPageAsJSON(ctx context.Context, page string, version string, language string) ([]byte, error) {
+	cacheKey := c.CacheKey("j", page, version, language)
+	return restclient.Bytes(ctx, c.cache, "directus", cacheKey, version != c.versionWhereCacheIgnored, func() ([]byte, error) {
+		params, err := c.cmsURLParams(page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: params: %w", cacheKey, err)
+		}
+		reqURL := c.cmsAPIUrl + url.PathEscape(c.Collection) + "?" + params
+		return restclient.Get(ctx, c.HttpClient, reqURL, c.cmsAuthToken, directusStatusDescriptions)
+	})
+}
+
+// GetPage returns {"entry": item} for a slug and {"entries": [item, ...]} for "", with translations merged into the items
+func (c *Client) GetPage(ctx context.Context, page string, version string, language string) (map[string]any, error) {
+	cacheKey := c.CacheKey("r", page, version, language)
+	return restclient.JSON(ctx, c.cache, "directus", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]any, error) {
+		items, err := c.translatedItems(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+		if page == "" {
+			return map[string]any{"entries": items}, nil
+		}
+		return map[string]any{"entry": items[0]}, nil
+	})
+}
+
+// GetPageAsSimpleBlocksWithID indexes the items in BodyField by their BlockIDField
+func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, version string, language string) (map[string]map[string]any, error) {
+	cacheKey := c.CacheKey("i", page, version, language)
+	return restclient.JSON(ctx, c.cache, "directus", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]map[string]any, error) {
+		items, err := c.translatedItems(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+
+		resp := map[string]map[string]any{}
+		blocks, _ := items[0][c.BodyField].([]any)
+		for _, b := range blocks {
+			block, ok := b.(map[string]any)
+			if !ok {
+				continue
+			}
+			// many-to-any junction rows wrap the block as {"collection": "block_text", "item": {...}}
+			if item, ok := block["item"].(map[string]any); ok {
+				block = item
+			}
+			id, ok := block[c.BlockIDField].(string)
+			if ok && len(id) > 0 {
+				resp[id] = block
+			}
+		}
+		return resp, nil
+	})
+}
+
+func (c *Client) CacheKey(prefix, page, version, language string) string {
+	return fmt.Sprint(prefix, ":", version, ":", language, ":", page)
+}
+
+// translatedItems returns the items of the response with translations merged, at least one
+func (c *Client) translatedItems(ctx context.Context, page string, version string, language string) ([]map[string]any, error) {
+	jsonResp, err := c.GetPageAsJSON(ctx, page, version, language)
+	if err != nil {
+		return nil, fmt.Errorf("request_json: %w", err)
+	}
+	cmsData := struct {
+		Data []map[string]any `json:"data"`
+	}{}
+	err = json.Unmarshal(jsonResp, &cmsData)
+	if err != nil {
+		return nil, fmt.Errorf("json_unmarshal: %w", err)
+	}
+	if len(cmsData.Data) == 0 {
+		return nil, fmt.Errorf("status: %d err: %w", http.StatusNotFound, errors.New(directusStatusDescriptions[http.StatusNotFound]))
+	}
+
+	items := make([]map[string]any, 0, len(cmsData.Data))
+	for _, item := range cmsData.Data {
+		translated, _ := c.mergeTranslations(item, c.languageCode(language)).(map[string]any)
+		items = append(items, translated)
+	}
+	return items, nil
+}
+
+// mergeTranslations copies node, replacing every translations junction with the fields of the
+// translation in languageCode. Ids, foreign keys (*_id) and the language of the junction row are left out.
+func (c *Client) mergeTranslations(node any, languageCode string) any {
+	switch n := node.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(n))
+		for k, v := range n {
+			if k == c.TranslationsField {
+				continue
+			}
+			out[k] = c.mergeTranslations(v, languageCode)
+		}
+		translations, ok := n[c.TranslationsField].([]any)
+		if !ok {
+			if _, exists := n[c.TranslationsField]; exists {
+				out[c.TranslationsField] = n[c.TranslationsField]
+			}
+			return out
+		}
+		for _, t := range translations {
+			translation, ok := t.(map[string]any)
+			if !ok || !matchesLanguage(translation[c.LanguageField], languageCode) {
+				continue
+			}
+			for k, v := range translation {
+				if k == "id" || k == c.LanguageField || strings.HasSuffix(k, "_id") {
+					continue
+				}
+				out[k] = c.mergeTranslations(v, languageCode)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(n))
+		for i, v := range n {
+			out[i] = c.mergeTranslations(v, languageCode)
+		}
+		return out
+	}
+	return node
+}
+
+// matchesLanguage accepts the language code or an expanded languages relation {"code": "de-DE"}
+func matchesLanguage(value any, languageCode string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == languageCode
+	case map[string]any:
+		return v["code"] == languageCode
+	}
+	return false
+}
+
+func (c *Client) languageCode(language string) string {
+	if code, ok := c.Languages[language]; ok {
+		return code
+	}
+	return language
+}
+
+func (c *Client) cmsURLParams(page, version, language string) (string, error) {
+	if version == "" {
+		version = c.versionDefault
+	}
+	params := url.Values{}
+	if page != "" {
+		params.Set("filter["+c.SlugField+"][_eq]", page)
+	}
+	if c.StatusField != "" && version != c.versionWhereCacheIgnored {
+		params.Set("filter["+c.StatusField+"][_eq]", "published")
+	}
+	if len(c.Fields) > 0 {
+		params.Set("fields", strings.Join(c.Fields, ","))
+	}
+
+	deep := map[string]any{}
+	for k, v := range c.Deep {
+		deep[k] = v
+	}
+	if language != "" && c.TranslationsField != "" {
+		deep[c.TranslationsField] = map[string]any{
+			"_filter": map[string]any{c.LanguageField: map[string]any{"_eq": c.languageCode(language)}},
+		}
+	}
+	if len(deep) > 0 {
+		deepJSON, err := json.Marshal(deep)
+		if err != nil {
+			return "", err
+		}
+		params.Set("deep", string(deepJSON))
+	}
+	return params.Encode(), nil
+}
+
+// https://docs.directus.io/reference/introduction.html#error-codes
+var directusStatusDescriptions = map[int]string{
+	200: "OK Everything worked as expected.",
+	400: "Bad Request Invalid query, e.g. an unknown field in filter, fields or deep.",
+	401: "Unauthorized The static token is invalid.",
+	403: "Forbidden The token has no permission for the collection or the collection doesn't exist.",
+	404: "Not Found The requested item doesn't exist (perhaps due to not yet published content entries).",
+	429: "Too Many Requests The rate limit of the instance was hit.",
+	500: "Internal Server Error Something went wrong in the Directus instance.",
+	503: "Service Unavailable The Directus instance is unavailable or under pressure.",
+}
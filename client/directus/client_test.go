@@ -0,0 +1,137 @@
+package directus_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/directus"
+	"github.com/dryaf/headless_cms/client/internal/clienttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const itemsResponse = `{
+	"data": [{
+		"id": 1,
+		"slug": "login",
+		"status": "published",
+		"translations": [
+			{"id": 10, "pages_id": 1, "languages_code": "de-DE", "title": "Anmelden"}
+		],
+		"blocks": [
+			{"id": 5, "collection": "block_text", "item": {
+				"id": 20, "block_id": "headline",
+				"translations": [
+					{"id": 30, "block_text_id": 20, "languages_code": "en-US", "text": "Welcome"},
+					{"id": 31, "block_text_id": 20, "languages_code": "de-DE", "text": "Willkommen"}
+				]
+			}},
+			{"id": 6, "collection": "block_text", "item": {"id": 21, "text": "no block id"}}
+		]
+	}]
+}`
+
+func newTestServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	return clienttest.NewServer(t, requests, func(r *http.Request) string {
+		if r.URL.Query().Get("filter[slug][_eq]") == "unknown" {
+			return `{"data": []}`
+		}
+		return itemsResponse
+	}, "static_token")
+}
+
+func newTestClient(server *httptest.Server) *directus.Client {
+	client := directus.NewClient(context.Background(), server.URL, "static_token", "empty_cache_token", memory_cache.New(), server.Client())
+	client.Languages = map[string]string{"de": "de-DE", "en": "en-US"}
+	client.Fields = []string{"*", "translations.*", "blocks.*", "blocks.item.*", "blocks.item.translations.*"}
+	client.Deep = map[string]any{"blocks": map[string]any{"_limit": 50}}
+	return client
+}
+
+func TestGetPageAsJSON(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPageAsJSON(ctx, "login", "published", "de")
+	require.NoError(t, err)
+	assert.JSONEq(t, itemsResponse, string(resp))
+	require.Len(t, requests, 1)
+	query := requests[0].URL.Query()
+	assert.Equal(t, "/items/pages", requests[0].URL.Path)
+	assert.Equal(t, "login", query.Get("filter[slug][_eq]"))
+	assert.Equal(t, "published", query.Get("filter[status][_eq]"))
+	assert.Equal(t, "*,translations.*,blocks.*,blocks.item.*,blocks.item.translations.*", query.Get("fields"))
+	deep := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(query.Get("deep")), &deep))
+	assert.Equal(t, map[string]any{
+		"blocks":       map[string]any{"_limit": float64(50)},
+		"translations": map[string]any{"_filter": map[string]any{"languages_code": map[string]any{"_eq": "de-DE"}}},
+	}, deep)
+
+	// cached
+	_, err = client.GetPageAsJSON(ctx, "login", "published", "de")
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// draft bypasses the cache and includes unpublished items
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "de")
+	require.NoError(t, err)
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "de")
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "", requests[2].URL.Query().Get("filter[status][_eq]"))
+}
+
+func TestGetPage(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPage(ctx, "login", "published", "de")
+	require.NoError(t, err)
+	entry := resp["entry"].(map[string]any)
+	assert.Equal(t, float64(1), entry["id"])
+	assert.Equal(t, "Anmelden", entry["title"])
+	assert.NotContains(t, entry, "translations")
+	assert.NotContains(t, entry, "pages_id")
+	block := entry["blocks"].([]any)[0].(map[string]any)["item"].(map[string]any)
+	assert.Equal(t, "Willkommen", block["text"])
+	assert.Equal(t, float64(20), block["id"])
+
+	cached, err := client.GetPage(ctx, "login", "published", "de")
+	require.NoError(t, err)
+	assert.Equal(t, resp, cached)
+	assert.Len(t, requests, 1)
+
+	_, err = client.GetPage(ctx, "unknown", "published", "de")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestGetPageAsSimpleBlocksWithID(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPageAsSimpleBlocksWithID(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, resp, 1)
+	assert.Equal(t, "Welcome", resp["headline"]["text"])
+}
+
+func TestErrorStatus(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := newTestServer(t, &requests)
+	client := directus.NewClient(ctx, server.URL, "wrong_token", "empty_cache_token", memory_cache.New(), server.Client())
+
+	_, err := client.GetPage(ctx, "login", "published", "de")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
@@ -0,0 +1,33 @@
+// Package clienttest holds the test server shared by the tests of the REST based clients.
+package clienttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewServer records the requests in requests and answers them with the body returned by respond.
+// Requests without one of tokens as bearer token get a 401, no tokens skip the check.
+func NewServer(t *testing.T, requests *[]*http.Request, respond func(r *http.Request) string, tokens ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		if len(tokens) > 0 && !authorized(r, tokens) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(respond(r)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func authorized(r *http.Request, tokens []string) bool {
+	for _, token := range tokens {
+		if r.Header.Get("Authorization") == "Bearer "+token {
+			return true
+		}
+	}
+	return false
+}
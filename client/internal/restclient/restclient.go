@@ -0,0 +1,117 @@
+// Package restclient holds the request and cache handling shared by the clients of REST based CMSs.
+package restclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Get requests reqURL as JSON, with token as bearer token unless it is empty, and returns the body of a 200 response.
+// descriptions explain the other statuses in the error, e.g. the error codes of the CMS documentation.
+func Get(ctx context.Context, httpClient HTTPClient, reqURL string, token string, descriptions map[int]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: %s: %w", reqURL, err)
+	}
+	req.Header.Add("Accept", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: %s: resp: %w", reqURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("headless_cms: %s: status: %d err: %w", reqURL, resp.StatusCode, errors.New(descriptions[resp.StatusCode]))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: %s: readBody: %w", reqURL, err)
+	}
+	return body, nil
+}
+
+// Bytes returns the value of key from cache, or the result of fetch which is cached.
+// cacheable false bypasses the cache, e.g. for drafts. cms prefixes the log messages, e.g. "strapi".
+func Bytes(ctx context.Context, cache headless_cms.Cache, cms string, key string, cacheable bool, fetch func() ([]byte, error)) ([]byte, error) {
+	if cache != nil && cacheable {
+		if obj := cacheGet(ctx, cache, cms, key); obj != nil {
+			return obj, nil
+		}
+	}
+
+	body, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil && cacheable {
+		cacheErr := cache.Set(ctx, key, body)
+		if cacheErr != nil {
+			slog.WarnContext(ctx, cms+" - cache.Set error", slog.String("url_params", key), slog.Any("err", cacheErr))
+		}
+	}
+	return body, nil
+}
+
+// JSON returns the value of key decoded from cache, or the result of build which is cached as JSON.
+// Cached values that don't decode into T are rebuilt.
+func JSON[T any](ctx context.Context, cache headless_cms.Cache, cms string, key string, cacheable bool, build func() (T, error)) (T, error) {
+	if cache != nil && cacheable {
+		if obj := cacheGet(ctx, cache, cms, key); obj != nil {
+			var v T
+			err := json.Unmarshal(obj, &v)
+			if err != nil {
+				slog.ErrorContext(ctx, fmt.Sprintf("%s - cache object not %T", cms, v),
+					slog.String("url_params", key), slog.Any("obj", obj))
+			} else {
+				return v, nil
+			}
+		}
+	}
+
+	v, err := build()
+	if err != nil {
+		return v, err
+	}
+
+	if cache != nil && cacheable {
+		jsonData, err := json.Marshal(v)
+		if err != nil {
+			slog.ErrorContext(ctx, cms+" - json marshal error", slog.Any("err", err), slog.String("key", key), slog.Any("data", v))
+		} else {
+			err = cache.Set(ctx, key, jsonData)
+			if err != nil {
+				slog.ErrorContext(ctx, cms+" - cache set error", slog.Any("err", err), slog.String("key", key), slog.Any("data", v))
+			}
+		}
+	}
+	return v, nil
+}
+
+func cacheGet(ctx context.Context, cache headless_cms.Cache, cms string, key string) []byte {
+	obj, cacheErr := cache.Get(ctx, key)
+	if cacheErr != nil || obj == nil {
+		slog.WarnContext(ctx, cms+" - cache.Get",
+			slog.String("url_params", key),
+			slog.Any("err", cacheErr),
+			slog.Bool("not_found", obj == nil))
+		return nil
+	}
+	return obj
+}
@@ -0,0 +1,77 @@
+package restclient_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/internal/clienttest"
+	"github.com/dryaf/headless_cms/client/internal/restclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := clienttest.NewServer(t, &requests, func(r *http.Request) string { return `{"ok": true}` }, "token")
+
+	body, err := restclient.Get(ctx, server.Client(), server.URL+"/items", "token", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok": true}`, string(body))
+	require.Len(t, requests, 1)
+	assert.Equal(t, "application/json", requests[0].Header.Get("Accept"))
+
+	_, err = restclient.Get(ctx, server.Client(), server.URL+"/items", "wrong", map[int]string{401: "Unauthorized"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status: 401 err: Unauthorized")
+}
+
+func TestBytes(t *testing.T) {
+	ctx := context.Background()
+	cache := memory_cache.New()
+	fetches := 0
+	fetch := func() ([]byte, error) {
+		fetches++
+		return []byte(`{}`), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		body, err := restclient.Bytes(ctx, cache, "test", "j:published::home", true, fetch)
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(body))
+	}
+	assert.Equal(t, 1, fetches)
+
+	_, err := restclient.Bytes(ctx, cache, "test", "j:draft::home", false, fetch)
+	require.NoError(t, err)
+	cached, _ := cache.Get(ctx, "j:draft::home")
+	assert.Nil(t, cached, "uncacheable values should not be cached")
+	assert.Equal(t, 2, fetches)
+}
+
+func TestJSON(t *testing.T) {
+	ctx := context.Background()
+	cache := memory_cache.New()
+	builds := 0
+	build := func() (map[string]any, error) {
+		builds++
+		return map[string]any{"entry": "home"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := restclient.JSON(ctx, cache, "test", "r:published::home", true, build)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"entry": "home"}, v)
+	}
+	assert.Equal(t, 1, builds)
+
+	// values that don't decode are rebuilt
+	_ = cache.Set(ctx, "r:published::home", []byte(`[1]`))
+	v, err := restclient.JSON(ctx, cache, "test", "r:published::home", true, build)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"entry": "home"}, v)
+	assert.Equal(t, 2, builds)
+}
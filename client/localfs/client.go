@@ -0,0 +1,368 @@
+// Package localfs serves stories from a directory of JSON files for offline development and CI.
+// Files are laid out as <language>/<full_slug>.json and contain the Storyblok CDN response {"story": {...}},
+// so the client behaves exactly like storyblok.Client, which it wraps.
+//
+// Snapshot directories (see package snapshot) are served as well: their stories are read from stories/ and
+// the space, links, tags and datasources from manifest.json, links.json, tags.json and datasources.json.
+// Without these files the space and the links are derived from the stories, tags and datasources are empty.
+package localfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+var _ headless_cms.Client = &Client{}
+
+// DefaultLanguage is the directory used when no language is requested
+const DefaultLanguage = "default"
+
+// SnapshotStoriesDir holds the language directories of a snapshot, next to its manifest.json
+const SnapshotStoriesDir = "stories"
+
+type Client struct {
+	*storyblok.Client
+
+	files *Files
+}
+
+func NewClient(ctx context.Context, dir string, emptyCacheToken string, cache headless_cms.Cache) *Client {
+	if _, err := os.Stat(dir); err != nil {
+		slog.ErrorContext(ctx, "localfs - Dir not readable", slog.String("dir", dir), slog.Any("err", err))
+		os.Exit(1)
+	}
+	files := &Files{FS: os.DirFS(dir), DefaultLanguage: DefaultLanguage}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err == nil {
+		files.StoriesDir = SnapshotStoriesDir
+	}
+	return &Client{
+		Client: storyblok.NewClient(ctx, "localfs", emptyCacheToken, cache, files),
+		files:  files,
+	}
+}
+
+// Watch polls the directory every interval until ctx is done and empties the cache when a file changed,
+// so dev servers show edits on the next request.
+func (c *Client) Watch(ctx context.Context, interval time.Duration) {
+	last, err := c.files.fingerprint()
+	if err != nil {
+		slog.ErrorContext(ctx, "localfs - fingerprint", slog.Any("err", err))
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := c.files.fingerprint()
+			if err != nil {
+				slog.ErrorContext(ctx, "localfs - fingerprint", slog.Any("err", err))
+				continue
+			}
+			if bytes.Equal(current, last) {
+				continue
+			}
+			last = current
+			if err := c.Cache().Empty(ctx); err != nil {
+				slog.ErrorContext(ctx, "localfs - cache.Empty", slog.Any("err", err))
+				continue
+			}
+			slog.InfoContext(ctx, "localfs - content changed, cache emptied")
+		}
+	}
+}
+
+// Files answers Storyblok CDN requests from a file system, it is used as storyblok.HTTPClient.
+// The stories, spaces/me, links, tags, datasources and datasource_entries endpoints are served.
+type Files struct {
+	FS              fs.FS
+	DefaultLanguage string
+	StoriesDir      string // directory of the language directories in FS, "" is the root
+}
+
+func (f *Files) Do(req *http.Request) (*http.Response, error) {
+	endpoint := strings.Trim(strings.TrimPrefix(req.URL.Path, "/v2/cdn"), "/")
+	params := req.URL.Query()
+	switch endpoint {
+	case "spaces/me":
+		return f.space()
+	case "links":
+		return f.links()
+	case "tags":
+		return f.listFile("tags.json", "tags")
+	case "datasources":
+		return f.listFile("datasources.json", "datasources")
+	case "datasource_entries":
+		return f.datasourceEntries(params.Get("datasource"), params.Get("dimension"))
+	}
+	if endpoint != "stories" && !strings.HasPrefix(endpoint, "stories/") {
+		return response(http.StatusNotFound, nil), nil
+	}
+
+	language := params.Get("language")
+	if language == "" {
+		language = f.DefaultLanguage
+	}
+	slug := strings.TrimPrefix(strings.TrimPrefix(endpoint, "stories"), "/")
+
+	if slug == "" {
+		return f.stories(language, params)
+	}
+
+	// Join would resolve ".." away, so validate the parts
+	if !fs.ValidPath(language) || !fs.ValidPath(slug) {
+		return response(http.StatusBadRequest, nil), nil
+	}
+	name := path.Join(f.StoriesDir, language, slug) + ".json"
+	body, err := fs.ReadFile(f.FS, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return response(http.StatusNotFound, nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return response(http.StatusOK, body), nil
+}
+
+// stories lists the stories of the language like the CDN stories endpoint, including starts_with,
+// page, per_page and the Total header
+func (f *Files) stories(language string, params url.Values) (*http.Response, error) {
+	if !fs.ValidPath(language) {
+		return response(http.StatusBadRequest, nil), nil
+	}
+	stories, err := f.list(language, params.Get("starts_with"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return response(http.StatusNotFound, nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+	total := len(stories)
+	if page, err := strconv.Atoi(params.Get("page")); err == nil && page > 0 {
+		perPage, err := strconv.Atoi(params.Get("per_page"))
+		if err != nil || perPage <= 0 {
+			perPage = 25
+		}
+		start := min((page-1)*perPage, total)
+		stories = stories[start:min(start+perPage, total)]
+	}
+	return listResponse("stories", stories, total)
+}
+
+// list returns the stories of the language whose full_slug starts with prefix
+func (f *Files) list(language string, prefix string) ([]json.RawMessage, error) {
+	root := path.Join(f.StoriesDir, language)
+	stories := []json.RawMessage{}
+	err := fs.WalkDir(f.FS, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".json" {
+			return err
+		}
+		body, err := fs.ReadFile(f.FS, name)
+		if err != nil {
+			return err
+		}
+		file := struct {
+			Story json.RawMessage `json:"story"`
+		}{}
+		if err := json.Unmarshal(body, &file); err != nil {
+			return fmt.Errorf("localfs: %s: %w", name, err)
+		}
+		story := struct {
+			FullSlug *string `json:"full_slug"`
+		}{}
+		if err := json.Unmarshal(file.Story, &story); err != nil {
+			return fmt.Errorf("localfs: %s: %w", name, err)
+		}
+		fullSlug := strings.TrimSuffix(strings.TrimPrefix(name, root+"/"), ".json")
+		if story.FullSlug != nil {
+			fullSlug = *story.FullSlug
+		}
+		if strings.HasPrefix(fullSlug, prefix) {
+			stories = append(stories, file.Story)
+		}
+		return nil
+	})
+	return stories, err
+}
+
+// space serves the space of manifest.json, or one with the language directories as language codes
+func (f *Files) space() (*http.Response, error) {
+	manifest := struct {
+		Space json.RawMessage `json:"space"`
+	}{}
+	data, err := fs.ReadFile(f.FS, "manifest.json")
+	if err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("localfs: manifest.json: %w", err)
+		}
+		return jsonResponse(map[string]any{"space": manifest.Space})
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	root := f.StoriesDir
+	if root == "" {
+		root = "."
+	}
+	entries, err := fs.ReadDir(f.FS, root)
+	if err != nil {
+		return nil, err
+	}
+	space := storyblok.Space{Name: "localfs", LanguageCodes: []string{}}
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != f.DefaultLanguage {
+			space.LanguageCodes = append(space.LanguageCodes, e.Name())
+		}
+	}
+	return jsonResponse(map[string]any{"space": space})
+}
+
+// links serves links.json, or links derived from the stories of the default language
+func (f *Files) links() (*http.Response, error) {
+	file := struct {
+		Links map[string]storyblok.Link `json:"links"`
+	}{}
+	found, err := f.readJSON("links.json", &file)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		stories, err := f.list(f.DefaultLanguage, "")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		file.Links = map[string]storyblok.Link{}
+		for _, raw := range stories {
+			story := struct {
+				ID          int    `json:"id"`
+				UUID        string `json:"uuid"`
+				Name        string `json:"name"`
+				FullSlug    string `json:"full_slug"`
+				IsFolder    bool   `json:"is_folder"`
+				IsStartpage bool   `json:"is_startpage"`
+				ParentID    int    `json:"parent_id"`
+				Position    int    `json:"position"`
+			}{}
+			if err := json.Unmarshal(raw, &story); err != nil || story.FullSlug == "" {
+				continue
+			}
+			key := story.UUID
+			if key == "" {
+				key = story.FullSlug
+			}
+			file.Links[key] = storyblok.Link{ID: story.ID, UUID: story.UUID, Slug: story.FullSlug, RealPath: "/" + story.FullSlug,
+				Name: story.Name, IsFolder: story.IsFolder, IsStartpage: story.IsStartpage, Published: true,
+				ParentID: story.ParentID, Position: story.Position}
+		}
+	}
+	return listResponse("links", file.Links, len(file.Links))
+}
+
+// listFile serves the list field of name, an empty list without the file
+func (f *Files) listFile(name string, field string) (*http.Response, error) {
+	file := map[string]json.RawMessage{}
+	if _, err := f.readJSON(name, &file); err != nil {
+		return nil, err
+	}
+	list := []json.RawMessage{}
+	if raw, ok := file[field]; ok {
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("localfs: %s: %w", name, err)
+		}
+	}
+	return listResponse(field, list, len(list))
+}
+
+// datasourceEntries serves the entries of datasources.json, {"entries": {<slug>: {<dimension>: [...]}}},
+// where dimension "" holds the default values
+func (f *Files) datasourceEntries(datasource string, dimension string) (*http.Response, error) {
+	file := struct {
+		Entries map[string]map[string][]json.RawMessage `json:"entries"`
+	}{}
+	if _, err := f.readJSON("datasources.json", &file); err != nil {
+		return nil, err
+	}
+	entries := file.Entries[datasource][dimension]
+	if entries == nil {
+		entries = []json.RawMessage{}
+	}
+	return listResponse("datasource_entries", entries, len(entries))
+}
+
+// readJSON decodes name into v, found is false when the file does not exist
+func (f *Files) readJSON(name string, v any) (found bool, err error) {
+	data, err := fs.ReadFile(f.FS, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("localfs: %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// listResponse answers like the paginated CDN endpoints, everything on the first page and the Total header
+func listResponse(field string, list any, total int) (*http.Response, error) {
+	resp, err := jsonResponse(map[string]any{field: list})
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set("Total", strconv.Itoa(total))
+	return resp, nil
+}
+
+func jsonResponse(v any) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return response(http.StatusOK, body), nil
+}
+
+// fingerprint changes whenever a file is added, removed or modified
+func (f *Files) fingerprint() ([]byte, error) {
+	h := sha256.New()
+	err := fs.WalkDir(f.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(h, name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func response(statusCode int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+}
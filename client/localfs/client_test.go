@@ -0,0 +1,158 @@
+package localfs_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/localfs"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeStory(t *testing.T, dir string, name string, content string) {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestGetPage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeStory(t, dir, "en/blog/first-post.json", `{"story": {"full_slug": "blog/first-post", "content": {"body": [{"id": "headline", "text": "Hello"}]}}}`)
+	writeStory(t, dir, "de/blog/first-post.json", `{"story": {"full_slug": "de/blog/first-post", "content": {"body": [{"id": "headline", "text": "Hallo"}]}}}`)
+	writeStory(t, dir, "default/home.json", `{"story": {"full_slug": "home"}}`)
+	client := localfs.NewClient(ctx, dir, "empty_cache_token", memory_cache.New())
+
+	resp, err := client.GetPage(ctx, "blog/first-post", "published", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "de/blog/first-post", resp["story"].(map[string]any)["full_slug"])
+
+	resp, err = client.GetPage(ctx, "home", "published", "")
+	require.NoError(t, err)
+	assert.Equal(t, "home", resp["story"].(map[string]any)["full_slug"])
+
+	blocks, err := client.GetPageAsSimpleBlocksWithID(ctx, "blog/first-post", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", blocks["headline"]["text"])
+
+	all, err := client.GetPage(ctx, "", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, all["stories"], 1)
+
+	_, err = client.GetPage(ctx, "missing", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	_, err = client.GetPage(ctx, "../de/blog/first-post", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+}
+
+func TestStories(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for i := 0; i < 150; i++ {
+		writeStory(t, dir, fmt.Sprintf("default/products/p%03d.json", i), fmt.Sprintf(`{"story": {"full_slug": "products/p%03d"}}`, i))
+	}
+	writeStory(t, dir, "default/home.json", `{"story": {"full_slug": "home"}}`)
+	client := localfs.NewClient(ctx, dir, "empty_cache_token", memory_cache.New())
+
+	stories, err := client.Stories(ctx, "published", "", nil)
+	require.NoError(t, err)
+	assert.Len(t, stories, 151)
+
+	stories, err = client.Stories(ctx, "published", "", url.Values{"starts_with": {"products/"}})
+	require.NoError(t, err)
+	require.Len(t, stories, 150)
+	assert.JSONEq(t, `{"full_slug": "products/p149"}`, string(stories[149]))
+}
+
+func TestEndpoints(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeStory(t, dir, "default/home.json", `{"story": {"id": 1, "uuid": "u1", "name": "Home", "full_slug": "home", "is_startpage": true}}`)
+	writeStory(t, dir, "de/home.json", `{"story": {"id": 1, "uuid": "u1", "full_slug": "de/home"}}`)
+	client := localfs.NewClient(ctx, dir, "empty_cache_token", memory_cache.New())
+
+	space, err := client.Space(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"de"}, space.LanguageCodes)
+
+	links, err := client.Links(ctx, "published")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]storyblok.Link{"u1": {ID: 1, UUID: "u1", Slug: "home", RealPath: "/home", Name: "Home", IsStartpage: true, Published: true}}, links)
+
+	tags, err := client.Tags(ctx, "published")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	value, err := client.DatasourceValue(ctx, "labels", "greeting", "")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	stats, err := client.Warm(ctx, storyblok.WarmOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+}
+
+func TestSnapshotLayout(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeStory(t, dir, "stories/default/home.json", `{"story": {"full_slug": "home"}}`)
+	writeStory(t, dir, "manifest.json", `{"format": 1, "space": {"id": 42, "language_codes": ["de", "fr"]}}`)
+	writeStory(t, dir, "links.json", `{"links": {"u1": {"uuid": "u1", "slug": "start"}}}`)
+	writeStory(t, dir, "tags.json", `{"tags": [{"name": "news", "taggings_count": 2}]}`)
+	writeStory(t, dir, "datasources.json", `{"datasources": [{"slug": "labels"}],
+		"entries": {"labels": {"": [{"name": "greeting", "value": "Hello"}], "de": [{"name": "greeting", "value": "Hello", "dimension_value": "Hallo"}]}}}`)
+	client := localfs.NewClient(ctx, dir, "empty_cache_token", memory_cache.New())
+
+	resp, err := client.GetPage(ctx, "home", "published", "")
+	require.NoError(t, err)
+	assert.Equal(t, "home", resp["story"].(map[string]any)["full_slug"])
+	stories, err := client.Stories(ctx, "published", "", nil)
+	require.NoError(t, err)
+	assert.Len(t, stories, 1)
+
+	space, err := client.Space(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 42, space.ID)
+	links, err := client.Links(ctx, "published")
+	require.NoError(t, err)
+	assert.Equal(t, "start", links["u1"].Slug)
+	tags, err := client.Tags(ctx, "published")
+	require.NoError(t, err)
+	assert.Equal(t, []storyblok.Tag{{Name: "news", TaggingsCount: 2}}, tags)
+	datasources, err := client.Datasources(ctx)
+	require.NoError(t, err)
+	assert.Len(t, datasources, 1)
+	value, err := client.DatasourceValue(ctx, "labels", "greeting", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "Hallo", value)
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	writeStory(t, dir, "en/home.json", `{"story": {"name": "v1"}}`)
+	client := localfs.NewClient(ctx, dir, "empty_cache_token", memory_cache.New())
+	go client.Watch(ctx, 10*time.Millisecond)
+
+	resp, err := client.GetPage(ctx, "home", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", resp["story"].(map[string]any)["name"])
+
+	time.Sleep(20 * time.Millisecond)
+	writeStory(t, dir, "en/home.json", `{"story": {"name": "version 2"}}`)
+	assert.Eventually(t, func() bool {
+		resp, err := client.GetPage(ctx, "home", "published", "en")
+		return err == nil && resp["story"].(map[string]any)["name"] == "version 2"
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,261 @@
+package sanity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/internal/restclient"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var _ headless_cms.Client = &Client{}
+
+// Client fetches documents through the Sanity HTTP query API with GROQ.
+// The "published" version reads the published perspective from the API CDN,
+// "draft" reads DraftPerspective from the live API and is never cached.
+type Client struct {
+	HttpClient HTTPClient
+
+	// CDNUrl and APIUrl default to the project hosts, e.g. https://<project>.apicdn.sanity.io/v2023-08-01/data/query/<dataset>
+	CDNUrl string
+	APIUrl string
+
+	// PageQuery fetches one page with the params $slug and $language ("" when not given)
+	PageQuery string
+	// AllPagesQuery fetches all pages for page "" with the param $language
+	AllPagesQuery string
+	// BodyField holds the objects indexed by their "id" in GetPageAsSimpleBlocksWithID
+	BodyField        string // "body"
+	DraftPerspective string // "previewDrafts"
+
+	cache                 headless_cms.Cache
+	cacheEmptyActionToken string
+
+	cmsAuthToken string
+
+	versionDefault           string // "published"
+	versionWhereCacheIgnored string // "draft"
+}
+
+const (
+	DefaultPageQuery     = `*[_type == "page" && slug.current == $slug && ($language == "" || language == $language)][0]`
+	DefaultAllPagesQuery = `*[_type == "page" && ($language == "" || language == $language)]`
+	DefaultAPIVersion    = "v2023-08-01"
+)
+
+// NewClient for dataset of projectID. The token is needed for private datasets and drafts.
+func NewClient(ctx context.Context, projectID string, dataset string, token string, emptyCacheToken string, cache headless_cms.Cache, httpClient HTTPClient) *Client {
+	if projectID == "" || dataset == "" {
+		slog.ErrorContext(ctx, "sanity - ProjectID or Dataset is empty")
+		os.Exit(1)
+	}
+	if emptyCacheToken == "" {
+		slog.ErrorContext(ctx, "sanity - EmptyCacheToken is empty")
+		os.Exit(1)
+	}
+	if cache == nil {
+		slog.ErrorContext(ctx, "sanity - Cache is nil")
+		os.Exit(1)
+	}
+	return &Client{
+		cache:                    cache,
+		cacheEmptyActionToken:    emptyCacheToken,
+		versionWhereCacheIgnored: "draft",
+
+		HttpClient:       httpClient,
+		CDNUrl:           "https://" + projectID + ".apicdn.sanity.io/" + DefaultAPIVersion + "/data/query/" + dataset,
+		APIUrl:           "https://" + projectID + ".api.sanity.io/" + DefaultAPIVersion + "/data/query/" + dataset,
+		PageQuery:        DefaultPageQuery,
+		AllPagesQuery:    DefaultAllPagesQuery,
+		BodyField:        "body",
+		DraftPerspective: "previewDrafts",
+
+		cmsAuthToken:   token,
+		versionDefault: "published",
+	}
+}
+
+func (c *Client) AuthToken() string {
+	return c.cmsAuthToken
+}
+
+func (c *Client) Cache() headless_cms.Cache {
+	return c.cache
+}
+
+func (c *Client) EmptyCache(ctx context.Context, token string) error {
+	if token != c.cacheEmptyActionToken {
+		return errors.New("token incorrect")
+	}
+	return c.cache.Empty(ctx)
+}
+
+func (c *Client) EmptyCacheToken(ctx context.Context) (string, error) {
+	if c.cacheEmptyActionToken == "" {
+		return "", errors.New("token not set")
+	}
+	return c.cacheEmptyActionToken, nil
+}
+
+// GetPageAsJSON returns the raw query response of PageQuery, or of AllPagesQuery for ""
+func (c *Client) GetPageAsJSON(ctx context.Context, page string, version string, language string) ([]byte, error) {
+	query, params := c.PageQuery, map[string]any{"slug": page, "language": language}
+	if page == "" {
+		query, params = c.AllPagesQuery, map[string]any{"language": language}
+	}
+	return c.query(ctx, c.CacheKey("j", page, version, language), query, params, version)
+}
+
+// Query runs any GROQ query on the published perspective and returns the JSON of its result.
+// Results are cached under a key derived from the query and the params.
+func (c *Client) Query(ctx context.Context, groq string, params map[string]any) ([]byte, error) {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: query params: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(groq+"\x00"), paramsJSON...))
+	body, err := c.query(ctx, c.CacheKey("q", hex.EncodeToString(sum[:]), c.versionDefault, ""), groq, params, c.versionDefault)
+	if err != nil {
+		return nil, err
+	}
+	resp := queryResponse{}
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: query: json_unmarshal: %w", err)
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) query(ctx context.Context, cacheKey string, groq string, params map[string]any, version string) ([]byte, error) {
+	return restclient.Bytes(ctx, c.cache, "sanity", cacheKey, version != c.versionWhereCacheIgnored, func() ([]byte, error) {
+		reqURL, err := c.cmsURL(groq, params, version)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: params: %w", cacheKey, err)
+		}
+		return restclient.Get(ctx, c.HttpClient, reqURL, c.cmsAuthToken, sanityStatusDescriptions)
+	})
+}
+
+// GetPage returns {"entry": document} for a slug and {"entries": [document, ...]} for ""
+func (c *Client) GetPage(ctx context.Context, page string, version string, language string) (map[string]any, error) {
+	cacheKey := c.CacheKey("r", page, version, language)
+	return restclient.JSON(ctx, c.cache, "sanity", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]any, error) {
+		result, err := c.pageResult(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+		if page == "" {
+			return map[string]any{"entries": result}, nil
+		}
+		return map[string]any{"entry": result}, nil
+	})
+}
+
+// GetPageAsSimpleBlocksWithID indexes the objects in BodyField by their "id" field
+func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, version string, language string) (map[string]map[string]any, error) {
+	cacheKey := c.CacheKey("i", page, version, language)
+	return restclient.JSON(ctx, c.cache, "sanity", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]map[string]any, error) {
+		result, err := c.pageResult(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+
+		resp := map[string]map[string]any{}
+		document, _ := result.(map[string]any)
+		blocks, _ := document[c.BodyField].([]any)
+		for _, b := range blocks {
+			block, ok := b.(map[string]any)
+			if !ok {
+				continue
+			}
+			id, ok := block["id"].(string)
+			if ok && len(id) > 0 {
+				resp[id] = block
+			}
+		}
+		return resp, nil
+	})
+}
+
+func (c *Client) CacheKey(prefix, page, version, language string) string {
+	return fmt.Sprint(prefix, ":", version, ":", language, ":", page)
+}
+
+// pageResult returns the result of the page query, a null result is reported as not found
+func (c *Client) pageResult(ctx context.Context, page string, version string, language string) (any, error) {
+	jsonResp, err := c.GetPageAsJSON(ctx, page, version, language)
+	if err != nil {
+		return nil, fmt.Errorf("request_json: %w", err)
+	}
+	resp := queryResponse{}
+	err = json.Unmarshal(jsonResp, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("json_unmarshal: %w", err)
+	}
+	var result any
+	if len(resp.Result) > 0 {
+		err = json.Unmarshal(resp.Result, &result)
+		if err != nil {
+			return nil, fmt.Errorf("json_unmarshal: %w", err)
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("status: %d err: %w", http.StatusNotFound, errors.New(sanityStatusDescriptions[http.StatusNotFound]))
+	}
+	return result, nil
+}
+
+func (c *Client) cmsURL(groq string, params map[string]any, version string) (string, error) {
+	if version == "" {
+		version = c.versionDefault
+	}
+	baseURL, perspective := c.CDNUrl, "published"
+	if version == c.versionWhereCacheIgnored {
+		baseURL, perspective = c.APIUrl, c.DraftPerspective
+	}
+
+	values := url.Values{}
+	values.Set("query", groq)
+	values.Set("perspective", perspective)
+	for name, value := range params {
+		// params are passed as JSON values
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		values.Set("$"+name, string(valueJSON))
+	}
+	return baseURL + "?" + values.Encode(), nil
+}
+
+// https://www.sanity.io/docs/http-query
+var sanityStatusDescriptions = map[int]string{
+	200: "OK Everything worked as expected.",
+	400: "Bad Request The GROQ query is invalid or a param is missing.",
+	401: "Unauthorized The token is invalid.",
+	403: "Forbidden The token has no read access to the dataset.",
+	404: "Not Found The document, dataset or project doesn't exist (perhaps due to not yet published documents).",
+	414: "URI Too Long The query and params are too long for a GET request.",
+	429: "Too Many Requests The rate limit of the project was hit.",
+	500: "Internal Server Error Something went wrong on Sanity's end.",
+	503: "Service Unavailable Sanity is temporarily unavailable.",
+}
+
+type queryResponse struct {
+	Query  string          `json:"query"`
+	Result json.RawMessage `json:"result"`
+	Ms     int             `json:"ms"`
+}
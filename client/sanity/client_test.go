@@ -0,0 +1,141 @@
+package sanity_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/internal/clienttest"
+	"github.com/dryaf/headless_cms/client/sanity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pageResponse = `{
+	"query": "*[_type == \"page\"][0]",
+	"result": {
+		"_id": "page-login",
+		"_type": "page",
+		"title": "Login",
+		"slug": {"current": "login"},
+		"body": [
+			{"_key": "a1", "_type": "text", "id": "headline", "text": "Welcome"},
+			{"_key": "a2", "_type": "text", "text": "no id"}
+		]
+	},
+	"ms": 3
+}`
+
+func newTestServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	return clienttest.NewServer(t, requests, func(r *http.Request) string {
+		switch r.URL.Query().Get("$slug") {
+		case `"unknown"`:
+			return `{"query": "", "result": null, "ms": 1}`
+		case `"login"`:
+			return pageResponse
+		}
+		return `{"query": "", "result": [{"title": "Login"}], "ms": 1}`
+	})
+}
+
+func newTestClient(server *httptest.Server) *sanity.Client {
+	client := sanity.NewClient(context.Background(), "project1", "production", "read_token", "empty_cache_token", memory_cache.New(), server.Client())
+	client.CDNUrl = server.URL + "/cdn"
+	client.APIUrl = server.URL + "/api"
+	return client
+}
+
+func TestGetPageAsJSON(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.JSONEq(t, pageResponse, string(resp))
+	require.Len(t, requests, 1)
+	query := requests[0].URL.Query()
+	assert.Equal(t, "/cdn", requests[0].URL.Path)
+	assert.Equal(t, sanity.DefaultPageQuery, query.Get("query"))
+	assert.Equal(t, `"login"`, query.Get("$slug"))
+	assert.Equal(t, `"en"`, query.Get("$language"))
+	assert.Equal(t, "published", query.Get("perspective"))
+	assert.Equal(t, "Bearer read_token", requests[0].Header.Get("Authorization"))
+
+	// cached
+	_, err = client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// draft bypasses the cache and reads drafts from the live API
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "en")
+	require.NoError(t, err)
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "en")
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "/api", requests[2].URL.Path)
+	assert.Equal(t, "previewDrafts", requests[2].URL.Query().Get("perspective"))
+}
+
+func TestGetPage(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPage(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	entry := resp["entry"].(map[string]any)
+	assert.Equal(t, "Login", entry["title"])
+
+	cached, err := client.GetPage(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, resp, cached)
+	assert.Len(t, requests, 1)
+
+	_, err = client.GetPage(ctx, "unknown", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	all, err := client.GetPage(ctx, "", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"title": "Login"}}, all["entries"])
+	assert.Equal(t, sanity.DefaultAllPagesQuery, requests[len(requests)-1].URL.Query().Get("query"))
+}
+
+func TestGetPageAsSimpleBlocksWithID(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	resp, err := client.GetPageAsSimpleBlocksWithID(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, resp, 1)
+	assert.Equal(t, "Welcome", resp["headline"]["text"])
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	client := newTestClient(newTestServer(t, &requests))
+
+	groq := `*[_type == "post" && category == $category]{title}`
+	result, err := client.Query(ctx, groq, map[string]any{"category": "news"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"title": "Login"}]`, string(result))
+	require.Len(t, requests, 1)
+	assert.Equal(t, groq, requests[0].URL.Query().Get("query"))
+	assert.Equal(t, `"news"`, requests[0].URL.Query().Get("$category"))
+
+	// same query and params are cached
+	_, err = client.Query(ctx, groq, map[string]any{"category": "news"})
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// other params are not
+	_, err = client.Query(ctx, groq, map[string]any{"category": "events"})
+	require.NoError(t, err)
+	assert.Len(t, requests, 2)
+}
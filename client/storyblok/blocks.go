@@ -0,0 +1,88 @@
+package storyblok
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BlockKeys selects the keys of GetPageAsSimpleBlocksWithID
+type BlockKeys int
+
+const (
+	// BlockKeysID keys blocks by their "id" field
+	BlockKeysID BlockKeys = iota
+	// BlockKeysPath keys blocks by the ids of their ancestors and their own, joined by "/",
+	// e.g. "pricing/title", so the same id may be used in different sections
+	BlockKeysPath
+)
+
+var ErrDuplicateBlockID = errors.New("duplicate block id")
+
+// IndexBlocks indexes the blocks with an "id" field in content at any depth and in any bloks field,
+// that is any list of objects; multilinks and assets are single objects or have numeric ids.
+// The last block of a duplicate key wins and the top-level blocks of "body" take precedence over
+// blocks nested in body, which take precedence over blocks of the other fields, visited in name order.
+// The duplicates are returned as errors wrapping ErrDuplicateBlockID together with the index.
+func IndexBlocks(content map[string]any, keys BlockKeys) (map[string]map[string]any, error) {
+	index := map[string]map[string]any{}
+	var errs []error
+	add := func(key string, block map[string]any) {
+		if _, ok := index[key]; ok {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrDuplicateBlockID, key))
+		}
+		index[key] = block
+	}
+	// top collects the top-level body blocks, they are added last
+	var top []map[string]any
+	var walk func(node any, path []string, inBody bool)
+	walk = func(node any, path []string, inBody bool) {
+		switch n := node.(type) {
+		case []any:
+			for _, child := range n {
+				block, ok := child.(map[string]any)
+				if !ok {
+					continue
+				}
+				blockPath := path
+				if id, _ := block["id"].(string); id != "" {
+					blockPath = append(path[:len(path):len(path)], id)
+					key := id
+					if keys == BlockKeysPath {
+						key = strings.Join(blockPath, "/")
+					}
+					if inBody {
+						top = append(top, block)
+					} else {
+						add(key, block)
+					}
+				}
+				walk(block, blockPath, false)
+			}
+		case map[string]any:
+			for _, field := range sortedFields(n) {
+				walk(n[field], path, false)
+			}
+		}
+	}
+	for _, field := range sortedFields(content) {
+		if field != "body" {
+			walk(content[field], nil, false)
+		}
+	}
+	walk(content["body"], nil, true)
+	for _, block := range top {
+		add(block["id"].(string), block)
+	}
+	return index, errors.Join(errs...)
+}
+
+func sortedFields(m map[string]any) []string {
+	fields := make([]string, 0, len(m))
+	for field := range m {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
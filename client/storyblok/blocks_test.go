@@ -0,0 +1,85 @@
+package storyblok_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nestedStory = `{"story": {"lang": "default", "content": {"component": "page", "body": [
+	{"component": "text", "id": "headline", "text": "Hello"},
+	{"component": "grid", "id": "pricing", "columns": [
+		{"component": "column", "items": [{"component": "text", "id": "title", "text": "Pricing"}]},
+		{"component": "text", "id": "footnote", "text": "*"}
+	]}
+], "sidebar": [{"component": "text", "id": "title", "text": "Sidebar"}],
+"link": {"id": "7a1c", "linktype": "story"}}}}`
+
+func TestIndexBlocks(t *testing.T) {
+	page := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(nestedStory), &page))
+	content := page["story"].(map[string]any)["content"].(map[string]any)
+
+	index, err := storyblok.IndexBlocks(content, storyblok.BlockKeysID)
+	require.ErrorIs(t, err, storyblok.ErrDuplicateBlockID)
+	assert.ElementsMatch(t, []string{"headline", "pricing", "title", "footnote"}, keys(index))
+	assert.Equal(t, "Pricing", index["title"]["text"], "body takes precedence over sidebar")
+	assert.Equal(t, "*", index["footnote"]["text"])
+
+	index, err = storyblok.IndexBlocks(content, storyblok.BlockKeysPath)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"headline", "pricing", "pricing/title", "pricing/footnote", "title"}, keys(index))
+	assert.Equal(t, "Sidebar", index["title"]["text"])
+}
+
+func TestIndexBlocksPrecedence(t *testing.T) {
+	content := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"aside": [{"id": "cta", "text": "aside"}, {"id": "note", "text": "aside"}],
+		"body": [
+			{"id": "cta", "text": "first"},
+			{"id": "grid", "columns": [{"id": "cta", "text": "nested"}, {"id": "note", "text": "nested"}]},
+			{"id": "cta", "text": "last"}
+		]}`), &content))
+
+	index, err := storyblok.IndexBlocks(content, storyblok.BlockKeysID)
+	require.ErrorIs(t, err, storyblok.ErrDuplicateBlockID)
+	assert.Equal(t, "last", index["cta"]["text"], "last top-level body block wins")
+	assert.Equal(t, "nested", index["note"]["text"], "nested body block wins over aside")
+}
+
+func TestGetPageAsSimpleBlocksWithIDNested(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), languageCDN{"": nestedStory})
+
+	blocks, err := client.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "")
+	require.NoError(t, err, "duplicates are logged")
+	assert.Equal(t, "Pricing", blocks["title"]["text"])
+
+	client.StrictBlockIDs = true
+	_, err = client.GetPageAsSimpleBlocksWithID(ctx, "home", "draft", "")
+	require.ErrorIs(t, err, storyblok.ErrDuplicateBlockID)
+
+	client.BlockKeys = storyblok.BlockKeysPath
+	blocks, err = client.GetPageAsSimpleBlocksWithID(ctx, "home", "draft", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Pricing", blocks["pricing/title"]["text"])
+
+	blocks, err = client.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Pricing", blocks["pricing/title"]["text"], "not served from the cache of the id keys")
+}
+
+func keys(m map[string]map[string]any) []string {
+	k := make([]string, 0, len(m))
+	for key := range m {
+		k = append(k, key)
+	}
+	return k
+}
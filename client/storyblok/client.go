@@ -2,12 +2,16 @@ package storyblok
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"log/slog"
@@ -22,11 +26,34 @@ type HTTPClient interface {
 var _ headless_cms.Client = &Client{}
 
 type Client struct {
-	HttpClient HTTPClient
+	HttpClient    HTTPClient
+	PreviewMaxAge time.Duration // 1h, lifetime of Visual Editor sessions
+
+	// LanguageFallbacks lists per language the languages to serve when a story is not translated,
+	// e.g. {"de-ch": {"de", ""}} where "" is the default language. nil disables fallbacks.
+	LanguageFallbacks map[string][]string
+	// FieldFallback fills empty or missing fields of GetPageAsSimpleBlocksWithID blocks from the fallback languages
+	FieldFallback bool // false
+
+	// BlockKeys selects the keys of GetPageAsSimpleBlocksWithID, ids or id paths
+	BlockKeys BlockKeys // BlockKeysID
+	// StrictBlockIDs fails GetPageAsSimpleBlocksWithID on duplicate keys instead of logging them
+	StrictBlockIDs bool // false
+
+	transformers []Transformer
+	pipelineHash string
 
 	cache                 headless_cms.Cache
 	cacheEmptyActionToken string
 
+	bus       headless_cms.InvalidationBus
+	busOrigin string
+
+	warmMu     sync.Mutex
+	warmCtx    context.Context
+	warmOpts   *WarmOptions
+	warmCancel context.CancelFunc
+
 	cmsAuthToken string
 	cmsAPIUrl    string
 
@@ -53,6 +80,7 @@ func NewClient(ctx context.Context, token string, emptyCacheToken string, cache
 		versionWhereCacheIgnored: "draft",
 
 		HttpClient:     httpClient,
+		PreviewMaxAge:  time.Hour,
 		cmsAPIUrl:      "https://api.storyblok.com/v2/cdn/stories",
 		cmsAuthToken:   token,
 		versionDefault: "published",
@@ -67,11 +95,110 @@ func (c *Client) Cache() headless_cms.Cache {
 	return c.cache
 }
 
+// CachesVersion reports whether stories of version are kept in the cache, drafts are always requested
+func (c *Client) CachesVersion(version string) bool {
+	return c.cache != nil && version != c.versionWhereCacheIgnored
+}
+
 func (c *Client) EmptyCache(ctx context.Context, token string) error {
 	if token != c.cacheEmptyActionToken {
 		return errors.New("token incorrect")
 	}
-	return c.cache.Empty(ctx)
+	err := c.cache.Empty(ctx)
+	if err != nil {
+		return err
+	}
+	c.rewarm()
+	return c.publish(ctx, headless_cms.Invalidation{Kind: headless_cms.InvalidateEmpty})
+}
+
+// PurgeTags drops the pages tagged with one of tags (see StoryTag and TagListTag) from the cache and, with an
+// invalidation bus, from the caches of the other instances. Caches without tag support are emptied.
+// Links, listings and datasources span several stories and are not tagged, EmptyCache drops them.
+func (c *Client) PurgeTags(ctx context.Context, token string, tags ...string) error {
+	if token != c.cacheEmptyActionToken {
+		return errors.New("token incorrect")
+	}
+	msg := headless_cms.Invalidation{Kind: headless_cms.InvalidateTags, Tags: tags}
+	err := headless_cms.ApplyInvalidation(ctx, c.cache, msg)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.cache.(headless_cms.TagPurger); !ok {
+		c.rewarm()
+	}
+	return c.publish(ctx, msg)
+}
+
+// StoryTag is the cache tag of the pages of the story with id, e.g. the story_id of a publish webhook
+func StoryTag(id int) string {
+	return "story:" + strconv.Itoa(id)
+}
+
+// TagListTag is the cache tag of the pages of the stories with tag in their tag_list
+func TagListTag(tag string) string {
+	return "tag:" + tag
+}
+
+// storyTagsOf returns the cache tags of a story response, nil for other responses
+func storyTagsOf(body []byte) []string {
+	resp := struct {
+		Story struct {
+			ID      int   `json:"id"`
+			TagList []any `json:"tag_list"`
+		} `json:"story"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	return StoryTags(resp.Story.ID, resp.Story.TagList)
+}
+
+// StoryTags returns the cache tags of the story with id and tag_list, see StoryTag and TagListTag
+func StoryTags(id int, tagList []any) []string {
+	if id == 0 {
+		return nil
+	}
+	tags := []string{StoryTag(id)}
+	for _, tag := range tagList {
+		if name, ok := tag.(string); ok {
+			tags = append(tags, TagListTag(name))
+		}
+	}
+	return tags
+}
+
+// SetInvalidationBus makes EmptyCache broadcast to the other instances and applies their broadcasts to the cache.
+// Use it with per instance caches like memory_cache, tiered_cache already propagates through its own bus.
+func (c *Client) SetInvalidationBus(ctx context.Context, bus headless_cms.InvalidationBus) error {
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return err
+	}
+	c.bus = bus
+	c.busOrigin = hex.EncodeToString(origin)
+	return bus.Subscribe(ctx, func(ctx context.Context, msg headless_cms.Invalidation) {
+		if msg.Origin == c.busOrigin {
+			return
+		}
+		err := headless_cms.ApplyInvalidation(ctx, c.cache, msg)
+		if err != nil {
+			slog.ErrorContext(ctx, "storyblok - apply invalidation", slog.Any("msg", msg), slog.Any("err", err))
+			return
+		}
+		_, purger := c.cache.(headless_cms.TagPurger)
+		if msg.Kind == headless_cms.InvalidateEmpty || (msg.Kind == headless_cms.InvalidateTags && !purger) {
+			c.rewarm()
+		}
+	})
+}
+
+func (c *Client) publish(ctx context.Context, msg headless_cms.Invalidation) error {
+	if c.bus == nil {
+		return nil
+	}
+	msg.Origin = c.busOrigin
+	return c.bus.Publish(ctx, msg)
 }
 
 func (c *Client) EmptyCacheToken(ctx context.Context) (string, error) {
@@ -83,6 +210,7 @@ func (c *Client) EmptyCacheToken(ctx context.Context) (string, error) {
 
 // GetPageAsJSON story for example /login or "" for getting all stories
 func (c *Client) GetPageAsJSON(ctx context.Context, page string, version string, language string) ([]byte, error) {
+	version = c.requestVersion(ctx, version)
 	cacheKey := c.CacheKey("j", page, version, language)
 
 	// Cache read
@@ -98,40 +226,59 @@ func (c *Client) GetPageAsJSON(ctx context.Context, page string, version string,
 		}
 	}
 
-	// Remote CMS
+	// Remote CMS, untranslated stories are requested in the fallback languages
+	body, status, err := c.requestJSON(ctx, page, version, language)
+	for _, fallback := range c.LanguageFallbacks[language] {
+		if status != http.StatusNotFound {
+			break
+		}
+		body, status, err = c.requestJSON(ctx, page, version, fallback)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body, err = c.transform(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+	}
+
+	// Cache - Write
+	if c.cache != nil && version != c.versionWhereCacheIgnored {
+		cacheErr := headless_cms.SetTagged(ctx, c.cache, cacheKey, body, storyTagsOf(body)...)
+		if cacheErr != nil {
+			slog.WarnContext(ctx, "storyblok - cache.Set error", slog.String("url_params", cacheKey), slog.Any("err", cacheErr))
+		}
+	}
+	return body, nil
+}
+
+func (c *Client) requestJSON(ctx context.Context, page string, version string, language string) ([]byte, int, error) {
 	reqURL := c.cmsAPIUrl + c.cmsURLParams(page, version, language) + "&token=" + c.cmsAuthToken
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("headless_cms: %s: %w", reqURL, err)
+		return nil, 0, fmt.Errorf("headless_cms: %s: %w", reqURL, err)
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("headless_cms: %s: resp: %w", reqURL, err)
+		return nil, 0, fmt.Errorf("headless_cms: %s: resp: %w", reqURL, err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("headless_cms: %s: status: %d err: %w", reqURL, resp.StatusCode, errors.New(storyblokStatusDescriptions[resp.StatusCode]))
+		return nil, resp.StatusCode, fmt.Errorf("headless_cms: %s: status: %d err: %w", reqURL, resp.StatusCode, errors.New(storyblokStatusDescriptions[resp.StatusCode]))
 	}
 
-	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("headless_cms: %s: readBody: %w", reqURL, err)
-	}
-
-	// Cache - Write
-	if c.cache != nil && version != c.versionWhereCacheIgnored {
-		cacheErr := c.cache.Set(ctx, cacheKey, body)
-		if cacheErr != nil {
-			slog.WarnContext(ctx, "storyblok - cache.Set error", slog.String("url_params", cacheKey), slog.Any("err", cacheErr))
-		}
+		return nil, resp.StatusCode, fmt.Errorf("headless_cms: %s: readBody: %w", reqURL, err)
 	}
-	return body, nil
+	return body, resp.StatusCode, nil
 }
 
 func (c *Client) GetPage(ctx context.Context, page string, version string, language string) (map[string]any, error) {
+	version = c.requestVersion(ctx, version)
 	cacheKey := c.CacheKey("r", page, version, language)
 	cmsData := map[string]any{}
 
@@ -170,7 +317,7 @@ func (c *Client) GetPage(ctx context.Context, page string, version string, langu
 		if err != nil {
 			slog.ErrorContext(ctx, "storyblok - json marshal error", slog.Any("err", err), slog.String("key", cacheKey), slog.Any("data", cmsData))
 		} else {
-			err = c.cache.Set(ctx, cacheKey, jsonData)
+			err = headless_cms.SetTagged(ctx, c.cache, cacheKey, jsonData, storyTagsOf(jsonResp)...)
 			if err != nil {
 				slog.ErrorContext(ctx, "storyblok - cache set error", slog.Any("err", err), slog.String("key", cacheKey), slog.Any("data", cmsData))
 			}
@@ -180,7 +327,8 @@ func (c *Client) GetPage(ctx context.Context, page string, version string, langu
 }
 
 func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, version string, language string) (map[string]map[string]any, error) {
-	cacheKey := c.CacheKey("i", page, version, language)
+	version = c.requestVersion(ctx, version)
+	cacheKey := c.CacheKey(c.blocksCachePrefix(), page, version, language)
 
 	// Cache - Read
 	if c.cache != nil && version != c.versionWhereCacheIgnored {
@@ -202,7 +350,31 @@ func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, v
 		}
 	}
 
-	// Remote CMS
+	resp, cmsData, err := c.simpleBlocks(ctx, page, version, language)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+	}
+	if len(c.LanguageFallbacks[language]) > 0 {
+		resp = c.fallbackFields(ctx, resp, cmsData.Story.Lang, page, version, language)
+	}
+
+	// Cache - Write
+	if c.cache != nil && version != c.versionWhereCacheIgnored {
+		jsonData, err := json.Marshal(resp)
+		if err != nil {
+			slog.ErrorContext(ctx, "storyblok - json marshal error", slog.Any("err", err), slog.String("cache_key", cacheKey), slog.Any("data", cmsData))
+		} else {
+			err = headless_cms.SetTagged(ctx, c.cache, cacheKey, jsonData, StoryTags(cmsData.Story.ID, cmsData.Story.TagList)...)
+			if err != nil {
+				slog.ErrorContext(ctx, "storyblok - cache set error", slog.Any("err", err), slog.String("cache_key", cacheKey), slog.Any("data", cmsData))
+			}
+		}
+	}
+	return resp, nil
+}
+
+// simpleBlocks indexes the blocks of the story at any depth, uncached
+func (c *Client) simpleBlocks(ctx context.Context, page string, version string, language string) (map[string]map[string]any, *SimpleBlockskWithID, error) {
 	cmsData := &SimpleBlockskWithID{
 		Story: Story{
 			Content:    Content{},
@@ -215,38 +387,47 @@ func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, v
 
 	jsonResp, err := c.GetPageAsJSON(ctx, page, version, language)
 	if err != nil {
-		return nil, fmt.Errorf("headless_cms: %s: request_json: %w", cacheKey, err)
+		return nil, nil, fmt.Errorf("request_json: %w", err)
 	}
 
 	err = json.Unmarshal(jsonResp, &cmsData)
 	if err != nil {
-		return nil, fmt.Errorf("headless_cms: %s: json_unmarshal: %w", cacheKey, err)
+		return nil, nil, fmt.Errorf("json_unmarshal: %w", err)
 	}
 
-	resp := map[string]map[string]any{}
-	for _, tt := range cmsData.Story.Content.Body {
-		id, ok := tt["id"].(string)
-		if ok && len(id) > 0 {
-			resp[id] = tt
-		}
+	raw := struct {
+		Story struct {
+			Content map[string]any `json:"content"`
+		} `json:"story"`
+	}{}
+	err = json.Unmarshal(jsonResp, &raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("json_unmarshal: %w", err)
 	}
 
-	// Cache - Write
-	if c.cache != nil && version != c.versionWhereCacheIgnored {
-		jsonData, err := json.Marshal(resp)
-		if err != nil {
-			slog.ErrorContext(ctx, "storyblok - json marshal error", slog.Any("err", err), slog.String("cache_key", cacheKey), slog.Any("data", cmsData))
-		} else {
-			err = c.cache.Set(ctx, cacheKey, jsonData)
-			if err != nil {
-				slog.ErrorContext(ctx, "storyblok - cache set error", slog.Any("err", err), slog.String("cache_key", cacheKey), slog.Any("data", cmsData))
-			}
+	resp, err := IndexBlocks(raw.Story.Content, c.BlockKeys)
+	if err != nil {
+		if c.StrictBlockIDs {
+			return nil, nil, fmt.Errorf("index_blocks: %w", err)
 		}
+		slog.ErrorContext(ctx, "storyblok - index blocks", slog.String("page", page), slog.String("language", language), slog.Any("err", err))
 	}
-	return resp, nil
+	return resp, cmsData, nil
+}
+
+// blocksCachePrefix is the CacheKey prefix of GetPageAsSimpleBlocksWithID, the key mode changes the result
+func (c *Client) blocksCachePrefix() string {
+	if c.BlockKeys == BlockKeysPath {
+		return "ip"
+	}
+	return "i"
 }
 
+// CacheKey returns prefix:version:language:page, with transformers the prefix carries the hash of the pipeline
 func (c *Client) CacheKey(prefix, page, version, language string) string {
+	if c.pipelineHash != "" {
+		prefix += "~" + c.pipelineHash
+	}
 	return fmt.Sprint(prefix, ":", version, ":", language, ":", page)
 }
 
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/cache/memory_cache"
 	"github.com/dryaf/headless_cms/cache/redis_cache"
 	"github.com/dryaf/headless_cms/client/storyblok"
 	"github.com/redis/go-redis/v9"
@@ -151,6 +152,25 @@ func TestRequestJSON(t *testing.T) {
 	cache.AssertExpectations(t)
 	mockHTTPClient.AssertExpectations(t)
 }
+func TestRequestJSONContext(t *testing.T) {
+	cache := &MockCache{}
+	mockHTTPClient := &MockHTTPClient{}
+	client := storyblok.NewClient(context.Background(), "test_token", "empty_cache_token", cache, mockHTTPClient)
+
+	// canceling the warmer or the request has to abort the request to Storyblok
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cache.On("Get", "j:published:en:login").Return(nil, nil)
+	mockHTTPClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Context().Err() == context.Canceled
+	})).Return(httpResponse(200, []byte(`{}`)), nil)
+	cache.On("Set", "j:published:en:login", []byte(`{}`)).Return(nil)
+
+	_, err := client.GetPageAsJSON(ctx, "login", "published", "en")
+	assert.Nil(t, err)
+	mockHTTPClient.AssertExpectations(t)
+}
+
 func TestEmptyCacheToken(t *testing.T) {
 	token := "cms_auth_token"
 	expectedToken := "empty_cache_token"
@@ -232,9 +252,10 @@ func TestRequestJSONError(t *testing.T) {
 	version := "draft"
 	language := "en"
 
+	body := &closeRecorder{Reader: bytes.NewReader([]byte(`{"error": "Server error"}`))}
 	mockResp := &http.Response{
 		StatusCode: 500,
-		Body:       io.NopCloser(bytes.NewReader([]byte(`{"error": "Server error"}`))),
+		Body:       body,
 	}
 
 	mockHTTPClient.On("Do", mock.Anything).Return(mockResp, nil)
@@ -242,10 +263,21 @@ func TestRequestJSONError(t *testing.T) {
 	resp, err := client.GetPageAsJSON(ctx, page, version, language)
 	assert.NotNil(t, err)
 	assert.Nil(t, resp)
+	assert.True(t, body.closed, "error body closed")
 
 	mockHTTPClient.AssertExpectations(t)
 }
 
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
 // ... Add similar tests for RequestTranslatableTexts, Request, and RequestSimpleBlocksWithID ...
 
 func TestGenerateKey(t *testing.T) {
@@ -568,3 +600,85 @@ func TestClient_Request(t *testing.T) {
 		assert.Equal(t, expectedResp, resp)
 	})
 }
+
+type MockBus struct {
+	handlers  []func(context.Context, headless_cms.Invalidation)
+	published []headless_cms.Invalidation
+}
+
+func (b *MockBus) Publish(ctx context.Context, msg headless_cms.Invalidation) error {
+	b.published = append(b.published, msg)
+	for _, h := range b.handlers {
+		h(ctx, msg)
+	}
+	return nil
+}
+
+func (b *MockBus) Subscribe(ctx context.Context, handler func(context.Context, headless_cms.Invalidation)) error {
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+func TestEmptyCacheInvalidationBus(t *testing.T) {
+	ctx := context.Background()
+	bus := &MockBus{}
+	cacheA := &MockCache{}
+	cacheB := &MockCache{}
+
+	clientA := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cacheA, &MockHTTPClient{})
+	clientB := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cacheB, &MockHTTPClient{})
+	require.NoError(t, clientA.SetInvalidationBus(ctx, bus))
+	require.NoError(t, clientB.SetInvalidationBus(ctx, bus))
+
+	// A empties its own cache once and B applies the broadcast
+	cacheA.On("Empty").Return(nil).Once()
+	cacheB.On("Empty").Return(nil).Once()
+	err := clientA.EmptyCache(ctx, "empty_cache_token")
+	assert.Nil(t, err)
+	assert.Len(t, bus.published, 1)
+	assert.Equal(t, headless_cms.InvalidateEmpty, bus.published[0].Kind)
+	cacheA.AssertExpectations(t)
+	cacheB.AssertExpectations(t)
+
+	// both apply a single key delete broadcast by another instance
+	cacheA.On("Del", "j:published:en:login").Return(nil).Once()
+	cacheB.On("Del", "j:published:en:login").Return(nil).Once()
+	err = bus.Publish(ctx, headless_cms.Invalidation{Kind: headless_cms.InvalidateDel, Keys: []string{"j:published:en:login"}, Origin: "other"})
+	assert.Nil(t, err)
+	cacheA.AssertExpectations(t)
+	cacheB.AssertExpectations(t)
+}
+
+func TestPurgeTags(t *testing.T) {
+	ctx := context.Background()
+	bus := &MockBus{}
+	cacheA := memory_cache.New()
+	cacheB := memory_cache.New()
+	cdn := languageCDN{"": `{"story": {"id": 7, "tag_list": ["news"], "content": {"component": "page", "body": [{"id": "headline", "text": "Hello"}]}}}`}
+
+	clientA := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cacheA, cdn)
+	clientB := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cacheB, cdn)
+	require.NoError(t, clientA.SetInvalidationBus(ctx, bus))
+	require.NoError(t, clientB.SetInvalidationBus(ctx, bus))
+	require.NoError(t, cacheA.Set(ctx, "other", []byte("{}")))
+	for _, client := range []*storyblok.Client{clientA, clientB} {
+		_, err := client.GetPage(ctx, "home", "published", "")
+		require.NoError(t, err)
+		_, err = client.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "")
+		require.NoError(t, err)
+	}
+
+	require.Error(t, clientA.PurgeTags(ctx, "wrong", storyblok.StoryTag(7)))
+	require.NoError(t, clientA.PurgeTags(ctx, "empty_cache_token", storyblok.TagListTag("news")))
+	assert.Equal(t, []string{"tag:news"}, bus.published[0].Tags)
+	for _, cache := range []*memory_cache.Cache{cacheA, cacheB} {
+		for _, prefix := range []string{"j", "r", "i"} {
+			obj, err := cache.Get(ctx, clientA.CacheKey(prefix, "home", "published", ""))
+			require.NoError(t, err)
+			assert.Nil(t, obj, prefix)
+		}
+	}
+	obj, err := cacheA.Get(ctx, "other")
+	require.NoError(t, err)
+	assert.NotNil(t, obj, "untagged entries are kept")
+}
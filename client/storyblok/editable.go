@@ -0,0 +1,51 @@
+package storyblok
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// Editable is the block info of the _editable comment of draft content,
+// <!--#storyblok#{"name": "teaser", "space": "1", "uid": "a-b", "id": "2"}-->
+type Editable struct {
+	Name  string `json:"name"`
+	Space string `json:"space"`
+	UID   string `json:"uid"`
+	ID    string `json:"id"`
+}
+
+// ParseEditable reads the _editable field of a block from GetPage, ok is false for blocks without one
+func ParseEditable(blok any) (Editable, bool) {
+	e := Editable{}
+	m, _ := blok.(map[string]any)
+	comment, _ := m["_editable"].(string)
+	if !strings.HasPrefix(comment, "<!--#storyblok#") || !strings.HasSuffix(comment, "-->") {
+		return e, false
+	}
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "<!--#storyblok#"), "-->")
+	if err := json.Unmarshal([]byte(comment), &e); err != nil || e.UID == "" {
+		return e, false
+	}
+	return e, true
+}
+
+// EditableAttrs returns the data-blok-c and data-blok-uid attributes the Visual Editor bridge needs
+// to make blok editable, only for validated editor sessions (see PreviewMiddleware).
+// The result is escaped and meant for use inside a tag, e.g. <div {{editable $.Ctx .}}>.
+func EditableAttrs(ctx context.Context, blok any) template.HTMLAttr {
+	if !IsPreview(ctx) {
+		return ""
+	}
+	e, ok := ParseEditable(blok)
+	if !ok {
+		return ""
+	}
+	c, err := json.Marshal(e)
+	if err != nil {
+		return ""
+	}
+	return template.HTMLAttr(`data-blok-c="` + html.EscapeString(string(c)) + `" data-blok-uid="` + html.EscapeString(e.ID+"-"+e.UID) + `"`)
+}
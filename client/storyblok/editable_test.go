@@ -0,0 +1,35 @@
+package storyblok_test
+
+import (
+	"context"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditableAttrs(t *testing.T) {
+	blok := map[string]any{
+		"component": "teaser",
+		"_editable": `<!--#storyblok#{"name": "teaser", "space": "1", "uid": "a-b", "id": "2\"><script>"}-->`,
+	}
+
+	e, ok := storyblok.ParseEditable(blok)
+	require.True(t, ok)
+	assert.Equal(t, "teaser", e.Name)
+	_, ok = storyblok.ParseEditable(map[string]any{"_editable": "<!-- other -->"})
+	assert.False(t, ok)
+
+	assert.Empty(t, storyblok.EditableAttrs(context.Background(), blok))
+
+	ctx := storyblok.WithPreview(context.Background())
+	tmpl := template.Must(template.New("blok").Funcs(template.FuncMap{"editable": storyblok.EditableAttrs}).
+		Parse(`<div {{editable .Ctx .Blok}}>{{.Blok.component}}</div>`))
+	out := &strings.Builder{}
+	require.NoError(t, tmpl.Execute(out, map[string]any{"Ctx": ctx, "Blok": blok}))
+	assert.Equal(t, `<div data-blok-c="{&#34;name&#34;:&#34;teaser&#34;,&#34;space&#34;:&#34;1&#34;,&#34;uid&#34;:&#34;a-b&#34;,&#34;id&#34;:&#34;2\&#34;\u003e\u003cscript\u003e&#34;}" data-blok-uid="2&#34;&gt;&lt;script&gt;-a-b">teaser</div>`, out.String())
+}
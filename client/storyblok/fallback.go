@@ -0,0 +1,74 @@
+package storyblok
+
+import (
+	"context"
+	"strings"
+
+	"log/slog"
+)
+
+// ServedLanguage returns the language of a GetPage result, which differs from the requested one when a
+// fallback was served. The default language is returned as "".
+func ServedLanguage(page map[string]any) string {
+	story, _ := page["story"].(map[string]any)
+	lang, _ := story["lang"].(string)
+	return servedLanguage(lang)
+}
+
+// servedLanguage maps the "lang" of a story to a language, storyblok reports the default language as "default"
+func servedLanguage(lang string) string {
+	if lang == "default" {
+		return ""
+	}
+	return lang
+}
+
+// fallbackFields records the served language of every block in "_lang" and, with FieldFallback, fills
+// empty fields and missing blocks from the fallback languages. Filled fields are recorded in "_fallback"
+// as field name to language. The default language is recorded as "", like ServedLanguage.
+func (c *Client) fallbackFields(ctx context.Context, blocks map[string]map[string]any, served string, page string, version string, language string) map[string]map[string]any {
+	served = servedLanguage(served)
+	for _, block := range blocks {
+		block["_lang"] = served
+	}
+	if !c.FieldFallback {
+		return blocks
+	}
+	for _, fallback := range c.LanguageFallbacks[language] {
+		fallbackBlocks, cmsData, err := c.simpleBlocks(ctx, page, version, fallback)
+		if err != nil {
+			slog.WarnContext(ctx, "storyblok - field fallback", slog.String("page", page), slog.String("language", fallback), slog.Any("err", err))
+			continue
+		}
+		lang := servedLanguage(cmsData.Story.Lang)
+		if lang == served {
+			continue
+		}
+		for id, fallbackBlock := range fallbackBlocks {
+			block, ok := blocks[id]
+			if !ok {
+				fallbackBlock["_lang"] = lang
+				blocks[id] = fallbackBlock
+				continue
+			}
+			for field, value := range fallbackBlock {
+				if strings.HasPrefix(field, "_") || !isEmptyField(block[field]) || isEmptyField(value) {
+					continue
+				}
+				block[field] = value
+				filled, _ := block["_fallback"].(map[string]any)
+				if filled == nil {
+					filled = map[string]any{}
+					block["_fallback"] = filled
+				}
+				filled[field] = lang
+			}
+		}
+	}
+	return blocks
+}
+
+func isEmptyField(v any) bool {
+	s, isString := v.(string)
+	return v == nil || (isString && strings.TrimSpace(s) == "")
+}
@@ -0,0 +1,69 @@
+package storyblok_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// languageCDN answers story requests by language, missing languages are 404
+type languageCDN map[string]string
+
+func (f languageCDN) Do(req *http.Request) (*http.Response, error) {
+	body, ok := f[req.URL.Query().Get("language")]
+	if !ok {
+		return httpResponse(http.StatusNotFound, nil), nil
+	}
+	return httpResponse(http.StatusOK, []byte(body)), nil
+}
+
+func TestLanguageFallbackStory(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), languageCDN{
+		"de": `{"story": {"lang": "de", "name": "Startseite"}}`,
+	})
+
+	_, err := client.GetPage(ctx, "home", "published", "de-ch")
+	require.Error(t, err, "no fallbacks configured")
+
+	client.LanguageFallbacks = map[string][]string{"de-ch": {"de", ""}}
+	page, err := client.GetPage(ctx, "home", "published", "de-ch")
+	require.NoError(t, err)
+	assert.Equal(t, "de", storyblok.ServedLanguage(page))
+
+	_, err = client.GetPage(ctx, "home", "published", "fr")
+	require.Error(t, err)
+}
+
+func TestLanguageFallbackFields(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), languageCDN{
+		"de-ch": `{"story": {"lang": "de-ch", "content": {"body": [{"id": "headline", "text": "Grüezi", "sub": ""}]}}}`,
+		"de":    `{"story": {"lang": "de", "content": {"body": [{"id": "headline", "text": "Hallo", "sub": "Willkommen"}, {"id": "footer", "text": "Impressum"}]}}}`,
+		"":      `{"story": {"lang": "default", "content": {"body": [{"id": "cta", "text": "Buy"}]}}}`,
+	})
+	client.LanguageFallbacks = map[string][]string{"de-ch": {"de", ""}}
+
+	blocks, err := client.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "de-ch")
+	require.NoError(t, err)
+	assert.Equal(t, "de-ch", blocks["headline"]["_lang"])
+	assert.Equal(t, "", blocks["headline"]["sub"], "without FieldFallback")
+	assert.Nil(t, blocks["footer"])
+
+	client.FieldFallback = true
+	require.NoError(t, client.Cache().Empty(ctx))
+	blocks, err = client.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "de-ch")
+	require.NoError(t, err)
+	assert.Equal(t, "Grüezi", blocks["headline"]["text"])
+	assert.Equal(t, "Willkommen", blocks["headline"]["sub"])
+	assert.Equal(t, map[string]any{"sub": "de"}, blocks["headline"]["_fallback"])
+	assert.Equal(t, "Impressum", blocks["footer"]["text"])
+	assert.Equal(t, "de", blocks["footer"]["_lang"])
+	assert.Equal(t, "", blocks["cta"]["_lang"], "default language")
+}
@@ -0,0 +1,161 @@
+package storyblok
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"log/slog"
+)
+
+// BlokTemplatePrefix names the sub-templates of the blok func, a "teaser" block is rendered by "blok_teaser"
+const BlokTemplatePrefix = "blok_"
+
+// FuncMap returns template funcs for GetPage and GetPageAsSimpleBlocksWithID data. t is the template set
+// the funcs are added to, blok looks up its sub-templates there:
+//
+//	t := template.New("site")
+//	t = template.Must(t.Funcs(client.FuncMap(t)).ParseGlob("templates/*.html"))
+//
+//	richtext .body                   rich text field as HTML
+//	link .link                       multilink field (story, url, email, asset) as URL
+//	image .image "800x0" "smart"     image service URL with raw operations
+//	(img .image).Resize 800 0        image service URL builder, see Image
+//	srcset .image 400 800 1200       srcset with one image service URL per width, .image can be an img
+//	datasource $.Ctx "labels" "key"  value of the datasource entry, an optional dimension follows the key
+//	blok $.Ctx .                     block rendered by the template blok_<component>
+//	text .Texts "id" "field"         field of a block by id, field defaults to "text"
+//	editable $.Ctx .                 Visual Editor attributes, see EditableAttrs
+//
+// $.Ctx is the context of the request, e.g. for draft versions and editor sessions. The blok_ templates
+// get the fields of the block and Ctx, so $.Ctx, editable $.Ctx . and nested blok calls work there too.
+func (c *Client) FuncMap(t *template.Template) template.FuncMap {
+	return template.FuncMap{
+		"richtext": func(doc any) template.HTML {
+			return template.HTML(RenderRichText(doc))
+		},
+		"link": LinkURL,
+		"image": func(asset any, params ...string) template.URL {
+			return templateURL(ImageURL(asset, params...))
+		},
+		"img": NewImage,
+		"srcset": func(asset any, widths ...int) template.Srcset {
+			return templateSrcset(NewImage(asset), widths...)
+		},
+		"datasource": func(ctx context.Context, datasource string, name string, dimension ...string) (string, error) {
+			d := ""
+			if len(dimension) > 0 {
+				d = dimension[0]
+			}
+			return c.DatasourceValue(ctx, datasource, name, d)
+		},
+		"blok": func(ctx context.Context, blok any) (template.HTML, error) {
+			return renderBlok(ctx, t, blok)
+		},
+		"text":     Text,
+		"editable": EditableAttrs,
+	}
+}
+
+func renderBlok(ctx context.Context, t *template.Template, blok any) (template.HTML, error) {
+	m, _ := blok.(map[string]any)
+	component, _ := m["component"].(string)
+	tmpl := t.Lookup(BlokTemplatePrefix + component)
+	if tmpl == nil {
+		return "", fmt.Errorf("storyblok: no template %q for component %q", BlokTemplatePrefix+component, component)
+	}
+	// a copy, the block itself may be cached or shared with other renderings
+	data := make(map[string]any, len(m)+1)
+	for k, v := range m {
+		data[k] = v
+	}
+	data["Ctx"] = ctx
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	// the sub-template escaped its output already
+	return template.HTML(buf.String()), nil
+}
+
+// LinkURL returns the URL of a multilink field, story links are relative to the site root
+func LinkURL(link any) string {
+	m, _ := link.(map[string]any)
+	linktype, _ := m["linktype"].(string)
+	u, _ := m["url"].(string)
+	switch linktype {
+	case "story":
+		if cached, _ := m["cached_url"].(string); cached != "" {
+			u = cached
+		}
+		if u != "" && !strings.HasPrefix(u, "/") {
+			u = "/" + u
+		}
+	case "email":
+		if email, _ := m["email"].(string); email != "" {
+			u = email
+		}
+		if u != "" && !strings.HasPrefix(u, "mailto:") {
+			u = "mailto:" + u
+		}
+	case "url", "asset":
+		if u == "" {
+			u, _ = m["cached_url"].(string)
+		}
+	}
+	if anchor, _ := m["anchor"].(string); anchor != "" && u != "" {
+		u += "#" + anchor
+	}
+	return u
+}
+
+// Text returns field of the block with id, field defaults to "text"
+func Text(blocks map[string]map[string]any, id string, field ...string) string {
+	f := "text"
+	if len(field) > 0 {
+		f = field[0]
+	}
+	text, _ := blocks[id][f].(string)
+	return text
+}
+
+// DatasourceValue returns the value of the entry name of datasource, in dimension when it is not "".
+// The entries are kept in the cache like stories.
+func (c *Client) DatasourceValue(ctx context.Context, datasource string, name string, dimension string) (string, error) {
+	cacheKey := c.CacheKey("d", datasource, c.versionDefault, dimension)
+	values := map[string]string{}
+
+	obj, err := c.cache.Get(ctx, cacheKey)
+	if err == nil && obj != nil && json.Unmarshal(obj, &values) == nil {
+		return values[name], nil
+	}
+
+	entries, err := c.DatasourceEntries(ctx, datasource, dimension)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		values[e.Name] = e.Value
+		if dimension != "" && e.DimensionValue != "" {
+			values[e.Name] = e.DimensionValue
+		}
+	}
+	if data, err := json.Marshal(values); err == nil {
+		if err := c.cache.Set(ctx, cacheKey, data); err != nil {
+			slog.WarnContext(ctx, "storyblok - cache.Set error", slog.String("key", cacheKey), slog.Any("err", err))
+		}
+	}
+	return values[name], nil
+}
+
+// RefreshDatasource replaces the cached values of DatasourceValue for datasource in dimension
+func (c *Client) RefreshDatasource(ctx context.Context, datasource string, dimension string) error {
+	if err := c.cache.Del(ctx, c.CacheKey("d", datasource, c.versionDefault, dimension)); err != nil {
+		return err
+	}
+	_, err := c.DatasourceValue(ctx, datasource, "", dimension)
+	return err
+}
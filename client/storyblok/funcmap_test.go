@@ -0,0 +1,65 @@
+package storyblok_test
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncMap(t *testing.T) {
+	ctx := context.Background()
+	mockHTTPClient := &MockHTTPClient{}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), mockHTTPClient)
+	mockHTTPClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/datasource_entries") && req.URL.Query().Get("dimension") == "de"
+	})).Return(httpResponse(http.StatusOK, []byte(`{"datasource_entries": [{"name": "greeting", "value": "Hello", "dimension_value": "Hallo"}]}`)), nil).Once()
+
+	tmpl := template.New("page")
+	tmpl = template.Must(tmpl.Funcs(client.FuncMap(tmpl)).Parse(
+		`{{richtext .Story.intro}}|<a href="{{link .Story.cta}}">{{datasource $.Ctx "labels" "greeting" "de"}}</a>|{{datasource $.Ctx "labels" "greeting" "de"}}|` +
+			`{{range .Story.body}}{{blok $.Ctx .}}{{end}}|{{text .Texts "headline"}}|<img src="{{image .Story.image "800x0"}}">` +
+			`{{define "blok_teaser"}}<p {{editable $.Ctx .}}>{{.title}}</p>{{end}}` +
+			`{{define "blok_grid"}}<div>{{range .columns}}{{blok $.Ctx .}}{{end}}</div>{{end}}`))
+
+	data := map[string]any{
+		"Story": map[string]any{
+			"intro": map[string]any{"type": "doc", "content": []any{map[string]any{"type": "paragraph", "content": []any{map[string]any{"type": "text", "text": "Hi"}}}}},
+			"cta":   map[string]any{"linktype": "story", "cached_url": "blog/post", "anchor": "top"},
+			"body": []any{
+				map[string]any{"component": "teaser", "title": "<Teaser>"},
+				map[string]any{"component": "grid", "columns": []any{map[string]any{"component": "teaser", "title": "Column",
+					"_editable": `<!--#storyblok#{"name": "teaser", "space": "1", "uid": "a-b", "id": "2"}-->`}}},
+			},
+			"image": map[string]any{"filename": "https://a.storyblok.com/f/1/x.jpg"},
+		},
+		"Texts": map[string]map[string]any{"headline": {"text": "Welcome"}},
+		"Ctx":   storyblok.WithPreview(ctx),
+	}
+	out := &strings.Builder{}
+	require.NoError(t, tmpl.Execute(out, data))
+	assert.Equal(t, `<p>Hi</p>|<a href="/blog/post#top">Hallo</a>|Hallo|<p >&lt;Teaser&gt;</p>`+
+		`<div><p data-blok-c="{&#34;name&#34;:&#34;teaser&#34;,&#34;space&#34;:&#34;1&#34;,&#34;uid&#34;:&#34;a-b&#34;,&#34;id&#34;:&#34;2&#34;}" data-blok-uid="2-a-b">Column</p></div>`+
+		`|Welcome|<img src="https://a.storyblok.com/f/1/x.jpg/m/800x0">`, out.String())
+	assert.NotContains(t, data["Story"].(map[string]any)["body"].([]any)[0], "Ctx", "block not modified")
+	mockHTTPClient.AssertExpectations(t)
+
+	err := tmpl.Execute(&strings.Builder{}, map[string]any{"Ctx": ctx, "Story": map[string]any{"body": []any{map[string]any{"component": "missing"}}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "blok_missing")
+}
+
+func TestLinkURL(t *testing.T) {
+	assert.Equal(t, "https://example.com", storyblok.LinkURL(map[string]any{"linktype": "url", "url": "https://example.com"}))
+	assert.Equal(t, "mailto:a@b.c", storyblok.LinkURL(map[string]any{"linktype": "email", "email": "a@b.c"}))
+	assert.Equal(t, "https://a.storyblok.com/f/1/x.pdf", storyblok.LinkURL(map[string]any{"linktype": "asset", "url": "https://a.storyblok.com/f/1/x.pdf"}))
+	assert.Equal(t, "", storyblok.LinkURL(nil))
+}
@@ -0,0 +1,196 @@
+package storyblok
+
+import (
+	"html/template"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AssetURLPattern matches absolute and protocol relative URLs in HTML and JSON, including the filters of
+// image service URLs like ".../m/800x0/filters:format(webp)". Submatch 1 is the host, 2 the path.
+var AssetURLPattern = regexp.MustCompile(`(?:https?:)?//([a-zA-Z0-9.-]+)(/(?:[^"'\s\\()<>?#]|\([^"'\s\\()<>?#]*\))+)`)
+
+// ImageURL returns the image service URL of an asset field or URL with the operations params,
+// e.g. ImageURL(asset, "800x0", "filters:format(webp)"). Without params the original is returned.
+func ImageURL(asset any, params ...string) string {
+	src := assetFilename(asset)
+	if src == "" || len(params) == 0 {
+		return src
+	}
+	return strings.TrimSuffix(src, "/") + "/m/" + strings.Join(params, "/")
+}
+
+// assetFilename reads asset fields {"filename": "https://..."} and plain URLs
+func assetFilename(asset any) string {
+	switch a := asset.(type) {
+	case string:
+		return a
+	case map[string]any:
+		filename, _ := a["filename"].(string)
+		return filename
+	}
+	return ""
+}
+
+// Image builds image service URLs, https://www.storyblok.com/docs/image-service.
+// The methods return modified copies, so a base image can be shared:
+//
+//	img := storyblok.NewImage(story["image"]).Format("webp").Quality(80)
+//	img.Resize(800, 0).URL()       https://a.storyblok.com/f/.../x.jpg/m/800x0/filters:format(webp):quality(80)
+//	img.SrcSet(400, 800, 1200)
+type Image struct {
+	src     string
+	width   int
+	height  int
+	fitIn   bool
+	smart   bool
+	focal   string
+	format  string
+	quality int
+	fill    string
+}
+
+// NewImage takes an asset field, URL or Image. The focus of asset fields, set in the asset manager, is used as
+// focal point unless smart cropping is requested.
+func NewImage(asset any) Image {
+	if img, ok := asset.(Image); ok {
+		return img
+	}
+	img := Image{src: assetFilename(asset)}
+	if m, ok := asset.(map[string]any); ok {
+		img.focal, _ = m["focus"].(string)
+	}
+	return img
+}
+
+// Resize to width x height, 0 keeps the aspect ratio. Both set crops to the center or focal point.
+func (i Image) Resize(width, height int) Image {
+	i.width, i.height, i.fitIn = max(width, 0), max(height, 0), false
+	return i
+}
+
+// FitIn resizes into width x height without cropping, Fill colors the remaining area
+func (i Image) FitIn(width, height int) Image {
+	i.width, i.height, i.fitIn = max(width, 0), max(height, 0), true
+	return i
+}
+
+// Smart crops around the detected faces or objects instead of the focal point
+func (i Image) Smart() Image {
+	i.smart = true
+	return i
+}
+
+// Focus sets the focal point as "x1xy1:x2xy2" in pixels of the original, like the focus of assets
+func (i Image) Focus(focal string) Image {
+	i.focal = focal
+	return i
+}
+
+// Format converts to "webp", "avif", "jpeg" or "png", others are ignored
+func (i Image) Format(format string) Image {
+	switch format {
+	case "webp", "avif", "jpeg", "png":
+		i.format = format
+	}
+	return i
+}
+
+// Quality from 0 to 100
+func (i Image) Quality(quality int) Image {
+	i.quality = min(max(quality, 0), 100)
+	return i
+}
+
+// Fill sets the background of FitIn, a hex color like "ffffff" or "transparent"
+func (i Image) Fill(color string) Image {
+	i.fill = strings.TrimPrefix(color, "#")
+	return i
+}
+
+// URL returns the image service URL, or the source unchanged for SVGs and non Storyblok URLs
+func (i Image) URL() string {
+	if !i.transformable() {
+		return i.src
+	}
+	segments := []string{}
+	if i.fitIn {
+		segments = append(segments, "fit-in")
+	}
+	if i.width > 0 || i.height > 0 {
+		segments = append(segments, strconv.Itoa(i.width)+"x"+strconv.Itoa(i.height))
+	}
+	if i.smart {
+		segments = append(segments, "smart")
+	}
+
+	filters := []string{}
+	// the focal point only matters when cropping
+	if i.focal != "" && !i.smart && !i.fitIn && i.width > 0 && i.height > 0 {
+		filters = append(filters, "focal("+i.focal+")")
+	}
+	if i.format != "" {
+		filters = append(filters, "format("+i.format+")")
+	}
+	if i.quality > 0 {
+		filters = append(filters, "quality("+strconv.Itoa(i.quality)+")")
+	}
+	if i.fill != "" && i.fitIn {
+		filters = append(filters, "fill("+i.fill+")")
+	}
+	if len(filters) > 0 {
+		segments = append(segments, "filters:"+strings.Join(filters, ":"))
+	}
+	if len(segments) == 0 {
+		return i.src
+	}
+	return strings.TrimSuffix(i.src, "/") + "/m/" + strings.Join(segments, "/")
+}
+
+func (i Image) String() string {
+	return i.URL()
+}
+
+// SrcSet returns a srcset attribute value with one URL per width, heights keep the aspect ratio of Resize
+func (i Image) SrcSet(widths ...int) string {
+	candidates := []string{}
+	for _, w := range widths {
+		if w <= 0 {
+			continue
+		}
+		c := i
+		c.width = w
+		if i.width > 0 && i.height > 0 {
+			c.height = i.height * w / i.width
+		}
+		candidates = append(candidates, c.URL()+" "+strconv.Itoa(w)+"w")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// templateURL marks URLs passing safeURL as safe for html/template, others like javascript: get the
+// replacement html/template uses for unsafe URLs
+func templateURL(u string) template.URL {
+	if safeURL(u) != u {
+		return "#ZgotmplZ"
+	}
+	return template.URL(u)
+}
+
+// templateSrcset marks the srcset of i as safe for html/template, so the filters of the URLs keep their parentheses
+func templateSrcset(i Image, widths ...int) template.Srcset {
+	if safeURL(i.src) != i.src || strings.ContainsAny(i.src, ", \t\n\r") {
+		return "#ZgotmplZ"
+	}
+	return template.Srcset(i.SrcSet(widths...))
+}
+
+func (i Image) transformable() bool {
+	u, err := url.Parse(i.src)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "storyblok.com") {
+		return false
+	}
+	return !strings.HasSuffix(strings.ToLower(u.Path), ".svg") && !strings.Contains(u.Path, "/m/")
+}
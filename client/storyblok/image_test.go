@@ -0,0 +1,53 @@
+package storyblok_test
+
+import (
+	"context"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const imageSrc = "https://a.storyblok.com/f/1/3310x2192/e4ec08624e/demo.jpeg"
+
+func TestImage(t *testing.T) {
+	asset := map[string]any{"filename": imageSrc, "focus": "1200x800:1201x801"}
+	img := storyblok.NewImage(asset)
+
+	assert.Equal(t, imageSrc, img.URL())
+	assert.Equal(t, imageSrc+"/m/800x600/filters:focal(1200x800:1201x801)", img.Resize(800, 600).URL())
+	assert.Equal(t, imageSrc+"/m/800x600/smart/filters:format(webp):quality(80)", img.Resize(800, 600).Smart().Format("webp").Quality(80).URL())
+	assert.Equal(t, imageSrc+"/m/fit-in/400x400/filters:fill(transparent)", img.FitIn(400, 400).Fill("transparent").URL())
+	assert.Equal(t, imageSrc+"/m/filters:format(avif)", img.Format("avif").Format("gif").URL())
+	assert.Equal(t, imageSrc+"/m/200x0", storyblok.NewImage(imageSrc).Resize(200, 0).URL())
+
+	assert.Equal(t, imageSrc+"/m/400x300 400w, "+imageSrc+"/m/800x600 800w",
+		storyblok.NewImage(imageSrc).Resize(800, 600).SrcSet(400, 800))
+
+	assert.Equal(t, "https://example.com/x.jpg", storyblok.NewImage("https://example.com/x.jpg").Resize(100, 0).URL())
+	assert.Equal(t, "https://a.storyblok.com/f/1/logo.svg", storyblok.NewImage("https://a.storyblok.com/f/1/logo.svg").Resize(100, 0).URL())
+	assert.Equal(t, "", storyblok.NewImage(nil).Resize(100, 0).URL())
+}
+
+func TestImageTemplate(t *testing.T) {
+	client := storyblok.NewClient(context.Background(), "test_token", "empty_cache_token", &MockCache{}, &MockHTTPClient{})
+	tmpl := template.New("page")
+	tmpl = template.Must(tmpl.Funcs(client.FuncMap(tmpl)).Parse(
+		`<img src="{{((img .).Resize 800 0).Format "webp"}}" srcset="{{srcset ((img .).Format "webp") 400 800}}">` +
+			`<img src="{{image . "200x0" "filters:format(webp)"}}">`))
+
+	out := &strings.Builder{}
+	require.NoError(t, tmpl.Execute(out, imageSrc))
+	// html/template escapes parentheses in src attributes even for template.URL, srcsets keep them
+	assert.Equal(t, `<img src="`+imageSrc+`/m/800x0/filters:format%28webp%29" `+
+		`srcset="`+imageSrc+`/m/400x0/filters:format(webp) 400w, `+imageSrc+`/m/800x0/filters:format(webp) 800w">`+
+		`<img src="`+imageSrc+`/m/200x0/filters:format%28webp%29">`, out.String())
+
+	out.Reset()
+	require.NoError(t, tmpl.Execute(out, "javascript:alert(1)"))
+	assert.Equal(t, `<img src="#ZgotmplZ" srcset="#ZgotmplZ"><img src="#ZgotmplZ">`, out.String())
+}
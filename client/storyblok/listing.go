@@ -0,0 +1,260 @@
+package storyblok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The listing endpoints are not cached, they are meant for exports, warming and generators.
+
+const storiesPerPage = 100
+
+type Space struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Domain        string   `json:"domain"`
+	Version       int      `json:"version"`
+	LanguageCodes []string `json:"language_codes"`
+}
+
+type Link struct {
+	ID          int    `json:"id"`
+	UUID        string `json:"uuid"`
+	Slug        string `json:"slug"`
+	RealPath    string `json:"real_path"`
+	Name        string `json:"name"`
+	IsFolder    bool   `json:"is_folder"`
+	IsStartpage bool   `json:"is_startpage"`
+	Published   bool   `json:"published"`
+	ParentID    int    `json:"parent_id"`
+	Position    int    `json:"position"`
+}
+
+type Tag struct {
+	Name          string `json:"name"`
+	TaggingsCount int    `json:"taggings_count"`
+}
+
+type Datasource struct {
+	ID         int                   `json:"id"`
+	Name       string                `json:"name"`
+	Slug       string                `json:"slug"`
+	Dimensions []DatasourceDimension `json:"dimensions"`
+}
+
+type DatasourceDimension struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	EntryValue string `json:"entry_value"`
+}
+
+type DatasourceEntry struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Value          string `json:"value"`
+	DimensionValue string `json:"dimension_value"`
+}
+
+// Space returns the space of the token including its language codes
+func (c *Client) Space(ctx context.Context) (Space, error) {
+	resp := struct {
+		Space Space `json:"space"`
+	}{}
+	_, err := c.getJSON(ctx, "spaces/me", url.Values{}, &resp)
+	return resp.Space, err
+}
+
+// Stories lists all stories of version and language, params can narrow the result, e.g. starts_with or sort_by.
+// The stories are returned as they come from the CDN after the transformers of Client.Use, so nothing is lost,
+// decode them into Story or maps as needed.
+func (c *Client) Stories(ctx context.Context, version string, language string, params url.Values) ([]json.RawMessage, error) {
+	stories := []json.RawMessage{}
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		p := c.listingParams(version, language, params, page, storiesPerPage)
+		resp := struct {
+			Stories []json.RawMessage `json:"stories"`
+		}{}
+		total, err := c.getJSON(ctx, "stories", p, &resp)
+		if err != nil {
+			return nil, err
+		}
+		// backends ignoring page answer with the same stories again
+		added := 0
+		for _, raw := range resp.Stories {
+			key := storyKey(raw)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			stories = append(stories, raw)
+			added++
+		}
+		if added == 0 || len(resp.Stories) < storiesPerPage || (total > 0 && len(stories) >= total) {
+			return c.transformStories(ctx, stories)
+		}
+	}
+}
+
+// transformStories runs the transformers of Client.Use on listed stories like on fetched stories
+func (c *Client) transformStories(ctx context.Context, stories []json.RawMessage) ([]json.RawMessage, error) {
+	if len(c.transformers) == 0 {
+		return stories, nil
+	}
+	body, err := json.Marshal(map[string]any{"stories": stories})
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: stories: json_marshal: %w", err)
+	}
+	body, err = c.transform(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("headless_cms: stories: %w", err)
+	}
+	resp := struct {
+		Stories []json.RawMessage `json:"stories"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("headless_cms: stories: json_unmarshal: %w", err)
+	}
+	return resp.Stories, nil
+}
+
+// storyKey identifies a listed story by uuid, id, full_slug or its JSON
+func storyKey(raw json.RawMessage) string {
+	story := struct {
+		UUID     string      `json:"uuid"`
+		ID       json.Number `json:"id"`
+		FullSlug string      `json:"full_slug"`
+	}{}
+	_ = json.Unmarshal(raw, &story)
+	switch {
+	case story.UUID != "":
+		return "uuid:" + story.UUID
+	case story.ID != "":
+		return "id:" + story.ID.String()
+	case story.FullSlug != "":
+		return "full_slug:" + story.FullSlug
+	}
+	return string(raw)
+}
+
+// Links returns all links of the space keyed by uuid
+func (c *Client) Links(ctx context.Context, version string) (map[string]Link, error) {
+	links := map[string]Link{}
+	for page := 1; ; page++ {
+		resp := struct {
+			Links map[string]Link `json:"links"`
+		}{}
+		total, err := c.getJSON(ctx, "links", c.listingParams(version, "", nil, page, 1000), &resp)
+		if err != nil {
+			return nil, err
+		}
+		for uuid, l := range resp.Links {
+			links[uuid] = l
+		}
+		if len(resp.Links) < 1000 || (total > 0 && len(links) >= total) {
+			return links, nil
+		}
+	}
+}
+
+func (c *Client) Tags(ctx context.Context, version string) ([]Tag, error) {
+	resp := struct {
+		Tags []Tag `json:"tags"`
+	}{}
+	_, err := c.getJSON(ctx, "tags", c.listingParams(version, "", nil, 0, 0), &resp)
+	return resp.Tags, err
+}
+
+func (c *Client) Datasources(ctx context.Context) ([]Datasource, error) {
+	datasources := []Datasource{}
+	for page := 1; ; page++ {
+		resp := struct {
+			Datasources []Datasource `json:"datasources"`
+		}{}
+		total, err := c.getJSON(ctx, "datasources", c.listingParams("", "", nil, page, 1000), &resp)
+		if err != nil {
+			return nil, err
+		}
+		datasources = append(datasources, resp.Datasources...)
+		if len(resp.Datasources) < 1000 || (total > 0 && len(datasources) >= total) {
+			return datasources, nil
+		}
+	}
+}
+
+// DatasourceEntries returns the entries of the datasource, dimension "" returns the default values
+func (c *Client) DatasourceEntries(ctx context.Context, datasource string, dimension string) ([]DatasourceEntry, error) {
+	params := url.Values{"datasource": []string{datasource}}
+	if dimension != "" {
+		params.Set("dimension", dimension)
+	}
+	entries := []DatasourceEntry{}
+	for page := 1; ; page++ {
+		resp := struct {
+			DatasourceEntries []DatasourceEntry `json:"datasource_entries"`
+		}{}
+		total, err := c.getJSON(ctx, "datasource_entries", c.listingParams("", "", params, page, 1000), &resp)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, resp.DatasourceEntries...)
+		if len(resp.DatasourceEntries) < 1000 || (total > 0 && len(entries) >= total) {
+			return entries, nil
+		}
+	}
+}
+
+func (c *Client) listingParams(version, language string, params url.Values, page, perPage int) url.Values {
+	p := url.Values{}
+	for k, v := range params {
+		p[k] = v
+	}
+	if version == "" {
+		version = c.versionDefault
+	}
+	p.Set("version", version)
+	if language != "" {
+		p.Set("language", language)
+	}
+	if page > 0 {
+		p.Set("page", strconv.Itoa(page))
+		p.Set("per_page", strconv.Itoa(perPage))
+	}
+	return p
+}
+
+// getJSON requests a CDN endpoint, decodes the body into v and returns the Total header of paginated endpoints
+func (c *Client) getJSON(ctx context.Context, endpoint string, params url.Values, v any) (int, error) {
+	params.Set("token", c.cmsAuthToken)
+	reqURL := strings.TrimSuffix(c.cmsAPIUrl, "/stories") + "/" + endpoint + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("headless_cms: %s: %w", endpoint, err)
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("headless_cms: %s: resp: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("headless_cms: %s: status: %d err: %w", endpoint, resp.StatusCode, errors.New(storyblokStatusDescriptions[resp.StatusCode]))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("headless_cms: %s: readBody: %w", endpoint, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return 0, fmt.Errorf("headless_cms: %s: json_unmarshal: %w", endpoint, err)
+	}
+	total, _ := strconv.Atoi(resp.Header.Get("Total"))
+	return total, nil
+}
@@ -0,0 +1,107 @@
+package storyblok_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func endpoint(path string) any {
+	return mock.MatchedBy(func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, path)
+	})
+}
+
+func TestStoriesPagination(t *testing.T) {
+	ctx := context.Background()
+	mockHTTPClient := &MockHTTPClient{}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", &MockCache{}, mockHTTPClient)
+
+	page := func(from, to int) []byte {
+		stories := []string{}
+		for i := from; i < to; i++ {
+			stories = append(stories, fmt.Sprintf(`{"id": %d}`, i))
+		}
+		return []byte(`{"stories": [` + strings.Join(stories, ",") + `]}`)
+	}
+	mockHTTPClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Query().Get("page") == "1" && req.URL.Query().Get("starts_with") == "blog/"
+	})).Return(httpResponse(http.StatusOK, page(0, 100)), nil).Once()
+	mockHTTPClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Query().Get("page") == "2" && req.URL.Query().Get("language") == "de"
+	})).Return(httpResponse(http.StatusOK, page(100, 120)), nil).Once()
+
+	stories, err := client.Stories(ctx, "published", "de", map[string][]string{"starts_with": {"blog/"}})
+	require.NoError(t, err)
+	assert.Len(t, stories, 120)
+	assert.JSONEq(t, `{"id": 119}`, string(stories[119]))
+	mockHTTPClient.AssertExpectations(t)
+}
+
+func TestStoriesPageIgnored(t *testing.T) {
+	ctx := context.Background()
+	stories := []string{}
+	for i := 0; i < 100; i++ {
+		stories = append(stories, fmt.Sprintf(`{"uuid": "u%d"}`, i))
+	}
+	// answers every page with the same stories
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", &MockCache{}, languageCDN{"": `{"stories": [` + strings.Join(stories, ",") + `]}`})
+
+	listed, err := client.Stories(ctx, "published", "", nil)
+	require.NoError(t, err)
+	assert.Len(t, listed, 100)
+}
+
+func TestListingEndpoints(t *testing.T) {
+	ctx := context.Background()
+	mockHTTPClient := &MockHTTPClient{}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", &MockCache{}, mockHTTPClient)
+
+	mockHTTPClient.On("Do", endpoint("/v2/cdn/spaces/me")).Return(httpResponse(http.StatusOK, []byte(`{"space": {"id": 1, "name": "Site", "language_codes": ["de", "fr"]}}`)), nil)
+	mockHTTPClient.On("Do", endpoint("/v2/cdn/links")).Return(httpResponse(http.StatusOK, []byte(`{"links": {"u1": {"id": 1, "uuid": "u1", "slug": "home", "published": true}}}`)), nil)
+	mockHTTPClient.On("Do", endpoint("/v2/cdn/tags")).Return(httpResponse(http.StatusOK, []byte(`{"tags": [{"name": "news", "taggings_count": 2}]}`)), nil)
+	mockHTTPClient.On("Do", endpoint("/v2/cdn/datasources")).Return(httpResponse(http.StatusOK, []byte(`{"datasources": [{"id": 1, "slug": "colors", "dimensions": [{"entry_value": "de"}]}]}`)), nil)
+	mockHTTPClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/v2/cdn/datasource_entries") && req.URL.Query().Get("datasource") == "colors" && req.URL.Query().Get("dimension") == "de"
+	})).Return(httpResponse(http.StatusOK, []byte(`{"datasource_entries": [{"name": "red", "value": "#f00", "dimension_value": "rot"}]}`)), nil)
+
+	space, err := client.Space(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"de", "fr"}, space.LanguageCodes)
+
+	links, err := client.Links(ctx, "published")
+	require.NoError(t, err)
+	assert.Equal(t, "home", links["u1"].Slug)
+
+	tags, err := client.Tags(ctx, "published")
+	require.NoError(t, err)
+	assert.Equal(t, []storyblok.Tag{{Name: "news", TaggingsCount: 2}}, tags)
+
+	datasources, err := client.Datasources(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "colors", datasources[0].Slug)
+
+	entries, err := client.DatasourceEntries(ctx, "colors", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "rot", entries[0].DimensionValue)
+}
+
+func TestListingError(t *testing.T) {
+	ctx := context.Background()
+	mockHTTPClient := &MockHTTPClient{}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", &MockCache{}, mockHTTPClient)
+	mockHTTPClient.On("Do", mock.Anything).Return(httpResponse(http.StatusUnauthorized, nil), nil)
+
+	_, err := client.Stories(ctx, "published", "", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.NotContains(t, err.Error(), "test_token")
+}
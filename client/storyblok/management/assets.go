@@ -0,0 +1,99 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+type Asset struct {
+	ID            int    `json:"id"`
+	Filename      string `json:"filename"` // public URL, e.g. "https://a.storyblok.com/f/1/400x300/abc/shoe.png"
+	AssetFolderID int    `json:"asset_folder_id,omitempty"`
+	Alt           string `json:"alt,omitempty"`
+}
+
+type signedUpload struct {
+	ID        int               `json:"id"`
+	PrettyURL string            `json:"pretty_url"`
+	PostURL   string            `json:"post_url"`
+	Fields    map[string]string `json:"fields"`
+}
+
+// UploadAsset uploads data as filename into the folder with folderID, 0 is the root folder.
+// The upload is signed by the API and sent to the asset storage directly.
+func (c *Client) UploadAsset(ctx context.Context, filename string, data []byte, folderID int) (Asset, error) {
+	signReq := map[string]any{"filename": path.Base(filename)}
+	if folderID != 0 {
+		signReq["asset_folder_id"] = folderID
+	}
+	// the size is part of the asset URL and used by the image service
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		signReq["size"] = fmt.Sprintf("%dx%d", config.Width, config.Height)
+	}
+	signed := signedUpload{}
+	if _, err := c.do(ctx, "POST", "assets", nil, signReq, &signed); err != nil {
+		return Asset{}, err
+	}
+
+	if err := c.postSigned(ctx, signed, path.Base(filename), data); err != nil {
+		return Asset{}, err
+	}
+
+	if _, err := c.do(ctx, "GET", "assets/"+strconv.Itoa(signed.ID)+"/finish_upload", nil, nil, nil); err != nil {
+		return Asset{}, err
+	}
+	filenameURL := signed.PrettyURL
+	if strings.HasPrefix(filenameURL, "//") {
+		filenameURL = "https:" + filenameURL
+	}
+	return Asset{ID: signed.ID, Filename: filenameURL, AssetFolderID: folderID}, nil
+}
+
+// postSigned sends the form fields of the signature followed by the file to the asset storage
+func (c *Client) postSigned(ctx context.Context, signed signedUpload, filename string, data []byte) error {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for name, value := range signed.Fields {
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	file, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", signed.PostURL, body)
+	if err != nil {
+		return fmt.Errorf("management: upload %s: %w", filename, err)
+	}
+	req.Header.Add("Content-Type", form.FormDataContentType())
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("management: upload %s: resp: %w", filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("management: upload %s: status: %d err: %s", filename, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package management
+
+import (
+	"context"
+	"strconv"
+)
+
+type Component struct {
+	ID                 int            `json:"id,omitempty"`
+	Name               string         `json:"name"`
+	DisplayName        string         `json:"display_name,omitempty"`
+	Schema             map[string]any `json:"schema,omitempty"` // field name to field definition, e.g. {"type": "text"}
+	IsRoot             bool           `json:"is_root"`
+	IsNestable         bool           `json:"is_nestable"`
+	ComponentGroupUUID string         `json:"component_group_uuid,omitempty"`
+}
+
+type componentRequest struct {
+	Component Component `json:"component"`
+}
+
+func (c *Client) Components(ctx context.Context) ([]Component, error) {
+	resp := struct {
+		Components []Component `json:"components"`
+	}{}
+	_, err := c.do(ctx, "GET", "components", nil, nil, &resp)
+	return resp.Components, err
+}
+
+func (c *Client) Component(ctx context.Context, id int) (Component, error) {
+	resp := componentRequest{}
+	_, err := c.do(ctx, "GET", "components/"+strconv.Itoa(id), nil, nil, &resp)
+	return resp.Component, err
+}
+
+func (c *Client) CreateComponent(ctx context.Context, component Component) (Component, error) {
+	resp := componentRequest{}
+	_, err := c.do(ctx, "POST", "components", nil, componentRequest{Component: component}, &resp)
+	return resp.Component, err
+}
+
+// UpdateComponent replaces the component with component.ID
+func (c *Client) UpdateComponent(ctx context.Context, component Component) (Component, error) {
+	resp := componentRequest{}
+	_, err := c.do(ctx, "PUT", "components/"+strconv.Itoa(component.ID), nil, componentRequest{Component: component}, &resp)
+	return resp.Component, err
+}
+
+func (c *Client) DeleteComponent(ctx context.Context, id int) error {
+	_, err := c.do(ctx, "DELETE", "components/"+strconv.Itoa(id), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,62 @@
+package management
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+type DatasourceEntry struct {
+	ID             int    `json:"id,omitempty"`
+	DatasourceID   int    `json:"datasource_id,omitempty"`
+	Name           string `json:"name"`
+	Value          string `json:"value"`
+	DimensionValue string `json:"dimension_value,omitempty"`
+}
+
+type datasourceEntryRequest struct {
+	Entry       DatasourceEntry `json:"datasource_entry"`
+	DimensionID int             `json:"dimension_id,omitempty"`
+}
+
+// DatasourceEntries returns all entries of the datasource, with the values of dimension when it is not ""
+func (c *Client) DatasourceEntries(ctx context.Context, datasourceID int, dimension string) ([]DatasourceEntry, error) {
+	params := url.Values{"datasource_id": {strconv.Itoa(datasourceID)}, "per_page": {"1000"}}
+	if dimension != "" {
+		params.Set("dimension", dimension)
+	}
+	entries := []DatasourceEntry{}
+	err := pages(func(page int) (int, int, error) {
+		params.Set("page", strconv.Itoa(page))
+		resp := struct {
+			Entries []DatasourceEntry `json:"datasource_entries"`
+		}{}
+		header, err := c.do(ctx, "GET", "datasource_entries", params, nil, &resp)
+		if err != nil {
+			return 0, 0, err
+		}
+		entries = append(entries, resp.Entries...)
+		return len(resp.Entries), total(header), nil
+	})
+	return entries, err
+}
+
+func (c *Client) CreateDatasourceEntry(ctx context.Context, entry DatasourceEntry) (DatasourceEntry, error) {
+	resp := struct {
+		Entry DatasourceEntry `json:"datasource_entry"`
+	}{}
+	_, err := c.do(ctx, "POST", "datasource_entries", nil, datasourceEntryRequest{Entry: entry}, &resp)
+	return resp.Entry, err
+}
+
+// UpdateDatasourceEntry updates the entry with entry.ID, DimensionValue is written to the dimension
+// with dimensionID when it is not 0
+func (c *Client) UpdateDatasourceEntry(ctx context.Context, entry DatasourceEntry, dimensionID int) error {
+	_, err := c.do(ctx, "PUT", "datasource_entries/"+strconv.Itoa(entry.ID), nil, datasourceEntryRequest{Entry: entry, DimensionID: dimensionID}, nil)
+	return err
+}
+
+func (c *Client) DeleteDatasourceEntry(ctx context.Context, id int) error {
+	_, err := c.do(ctx, "DELETE", "datasource_entries/"+strconv.Itoa(id), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,196 @@
+// Package management writes content through the Storyblok Management API: stories, datasource entries,
+// assets and components.
+//
+//	client := management.NewClient(ctx, spaceID, management.PersonalToken(os.Getenv("STORYBLOK_MANAGEMENT_TOKEN")), &http.Client{})
+//	story, err := client.CreateStory(ctx, management.Story{Name: "Shoe", Slug: "shoe", ParentID: productsID,
+//		Content: map[string]any{"component": "product", "sku": "4711"}}, true)
+//
+// Requests are spaced to the rate limit of the API, requests hit by the limit anyway are retried.
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+// ErrNotFound is wrapped by the errors of requests for missing stories, entries, assets or components
+var ErrNotFound = errors.New("not found")
+
+// TokenSource returns the value of the Authorization header
+type TokenSource interface {
+	Authorization(ctx context.Context) (string, error)
+}
+
+// PersonalToken is a personal access token of the Storyblok account settings
+type PersonalToken string
+
+func (t PersonalToken) Authorization(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+type Client struct {
+	HttpClient storyblok.HTTPClient
+	BaseURL    string // "https://mapi.storyblok.com/v1", e.g. "https://api-us.storyblok.com/v1" for US spaces
+	RateLimit  int    // 3 requests per second, 6 on paid plans
+	MaxRetries int    // 3, retries of requests hit by the rate limit
+
+	spaceID int
+	token   TokenSource
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func NewClient(ctx context.Context, spaceID int, token TokenSource, httpClient storyblok.HTTPClient) *Client {
+	if spaceID == 0 {
+		slog.ErrorContext(ctx, "management - SpaceID is empty")
+		os.Exit(1)
+	}
+	if token == nil {
+		slog.ErrorContext(ctx, "management - Token is nil")
+		os.Exit(1)
+	}
+	return &Client{
+		HttpClient: httpClient,
+		BaseURL:    "https://mapi.storyblok.com/v1",
+		RateLimit:  3,
+		MaxRetries: 3,
+		spaceID:    spaceID,
+		token:      token,
+	}
+}
+
+func (c *Client) SpaceID() int {
+	return c.spaceID
+}
+
+// wait spaces the requests to the rate limit
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	if c.next.Before(now) {
+		c.next = now
+	}
+	at := c.next
+	c.next = at.Add(time.Second / time.Duration(max(c.RateLimit, 1)))
+	c.mu.Unlock()
+	return sleep(ctx, at.Sub(now))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// do sends in as JSON to the endpoint of the space and decodes the response into out, both may be nil
+func (c *Client) do(ctx context.Context, method string, endpoint string, params url.Values, in any, out any) (http.Header, error) {
+	reqURL := strings.TrimSuffix(c.BaseURL, "/") + "/spaces/" + strconv.Itoa(c.spaceID) + "/" + endpoint
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+	var reqBody []byte
+	if in != nil {
+		var err error
+		reqBody, err = json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("management: %s %s: json_marshal: %w", method, endpoint, err)
+		}
+	}
+	authorization, err := c.token.Authorization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("management: %s %s: token: %w", method, endpoint, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("management: %s %s: %w", method, endpoint, err)
+		}
+		req.Header.Add("Authorization", authorization)
+		req.Header.Add("Accept", "application/json")
+		if in != nil {
+			req.Header.Add("Content-Type", "application/json")
+		}
+
+		resp, err := c.HttpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("management: %s %s: resp: %w", method, endpoint, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("management: %s %s: readBody: %w", method, endpoint, err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < c.MaxRetries {
+			backoff := time.Duration(1<<attempt) * time.Second
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				backoff = time.Duration(seconds) * time.Second
+			}
+			slog.WarnContext(ctx, "management - rate limited", slog.String("endpoint", endpoint), slog.Duration("backoff", backoff))
+			if err := sleep(ctx, backoff); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("management: %s %s: status: %d err: %w", method, endpoint, resp.StatusCode, ErrNotFound)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("management: %s %s: status: %d err: %w", method, endpoint, resp.StatusCode, errors.New(strings.TrimSpace(string(body))))
+		}
+		if out != nil && len(body) > 0 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return nil, fmt.Errorf("management: %s %s: json_unmarshal: %w", method, endpoint, err)
+			}
+		}
+		return resp.Header, nil
+	}
+}
+
+// pages calls fetch for every page of a paginated listing until total entries are read
+func pages(fetch func(page int) (n int, total int, err error)) error {
+	read := 0
+	for page := 1; ; page++ {
+		n, total, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		read += n
+		if n == 0 || read >= total {
+			return nil
+		}
+	}
+}
+
+func total(header http.Header) int {
+	total, _ := strconv.Atoi(header.Get("Total"))
+	return total
+}
@@ -0,0 +1,208 @@
+package management_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dryaf/headless_cms/client/storyblok/management"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	status int
+	body   string
+	header http.Header
+}
+
+// fakeAPI answers "METHOD host/path" with the queued responses, the last one is repeated
+type fakeAPI struct {
+	mu        sync.Mutex
+	responses map[string][]response
+	requests  []*http.Request
+	bodies    []string
+}
+
+func (f *fakeAPI) Do(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	f.requests = append(f.requests, req)
+	f.bodies = append(f.bodies, string(body))
+
+	key := req.Method + " " + req.URL.Host + req.URL.Path
+	queue := f.responses[key]
+	if len(queue) == 0 {
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"error": "not found"}`))}, nil
+	}
+	resp := queue[0]
+	if len(queue) > 1 {
+		f.responses[key] = queue[1:]
+	}
+	header := resp.header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: resp.status, Header: header, Body: io.NopCloser(strings.NewReader(resp.body))}, nil
+}
+
+func newClient(api *fakeAPI) *management.Client {
+	client := management.NewClient(context.Background(), 42, management.PersonalToken("personal"), api)
+	client.RateLimit = 1000
+	return client
+}
+
+const space = "mapi.storyblok.com/v1/spaces/42/"
+
+func TestStories(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{responses: map[string][]response{
+		"POST " + space + "stories":            {{status: 201, body: `{"story": {"id": 7, "name": "Shoe", "slug": "shoe", "full_slug": "products/shoe", "published_at": null}}`}},
+		"PUT " + space + "stories/7":           {{status: 200, body: `{"story": {"id": 7, "name": "Shoe 2", "slug": "shoe"}}`}},
+		"GET " + space + "stories/7/publish":   {{status: 200, body: `{"story": {"id": 7}}`}},
+		"GET " + space + "stories/7/unpublish": {{status: 200, body: `{"story": {"id": 7}}`}},
+		"DELETE " + space + "stories/7":        {{status: 200, body: `{"story": {"id": 7}}`}},
+		"GET " + space + "stories": {
+			{status: 200, body: `{"stories": [{"id": 1}, {"id": 2}]}`, header: http.Header{"Total": {"3"}}},
+			{status: 200, body: `{"stories": [{"id": 3}]}`, header: http.Header{"Total": {"3"}}},
+		},
+	}}
+	client := newClient(api)
+
+	story, err := client.CreateStory(ctx, management.Story{Name: "Shoe", Slug: "shoe", ParentID: 5,
+		Content: map[string]any{"component": "product", "sku": "4711"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 7, story.ID)
+	assert.Equal(t, "products/shoe", story.FullSlug)
+	assert.Nil(t, story.PublishedAt)
+	assert.Equal(t, "personal", api.requests[0].Header.Get("Authorization"))
+	assert.JSONEq(t, `{"story": {"name": "Shoe", "slug": "shoe", "parent_id": 5, "content": {"component": "product", "sku": "4711"}}, "publish": 1}`, api.bodies[0])
+
+	story.Name = "Shoe 2"
+	story.ParentID = 0
+	story, err = client.UpdateStory(ctx, story, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Shoe 2", story.Name)
+	request := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(api.bodies[1]), &request))
+	assert.Equal(t, "1", request["force_update"])
+	assert.Equal(t, float64(0), request["story"].(map[string]any)["parent_id"], "moved to the root")
+	assert.NotContains(t, request, "publish")
+
+	require.NoError(t, client.PublishStory(ctx, 7, "de", "en"))
+	assert.Equal(t, "de,en", api.requests[2].URL.Query().Get("lang"))
+	require.NoError(t, client.UnpublishStory(ctx, 7))
+	require.NoError(t, client.DeleteStory(ctx, 7))
+
+	_, err = client.Story(ctx, 8)
+	require.ErrorIs(t, err, management.ErrNotFound)
+
+	stories, err := client.Stories(ctx, management.StoriesOptions{StartsWith: "products/"})
+	require.NoError(t, err)
+	assert.Len(t, stories, 3)
+	last := api.requests[len(api.requests)-1].URL.Query()
+	assert.Equal(t, "products/", last.Get("starts_with"))
+	assert.Equal(t, "2", last.Get("page"))
+}
+
+func TestRateLimited(t *testing.T) {
+	ctx := context.Background()
+	limited := response{status: http.StatusTooManyRequests, body: `{"error": "Throttled"}`, header: http.Header{"Retry-After": {"0"}}}
+	api := &fakeAPI{responses: map[string][]response{
+		"GET " + space + "components":   {limited, {status: 200, body: `{"components": [{"id": 1, "name": "page", "is_root": true}]}`}},
+		"GET " + space + "components/1": {limited},
+	}}
+	client := newClient(api)
+
+	components, err := client.Components(ctx)
+	require.NoError(t, err)
+	require.Len(t, components, 1)
+	assert.True(t, components[0].IsRoot)
+	assert.Len(t, api.requests, 2)
+
+	_, err = client.Component(ctx, 1)
+	require.ErrorContains(t, err, "status: 429")
+	assert.Len(t, api.requests, 2+1+client.MaxRetries)
+}
+
+func TestDatasourceEntries(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{responses: map[string][]response{
+		"GET " + space + "datasource_entries":   {{status: 200, body: `{"datasource_entries": [{"id": 1, "name": "red", "value": "#f00", "dimension_value": "rot"}]}`, header: http.Header{"Total": {"1"}}}},
+		"POST " + space + "datasource_entries":  {{status: 201, body: `{"datasource_entry": {"id": 2, "name": "blue", "value": "#00f"}}`}},
+		"PUT " + space + "datasource_entries/2": {{status: 204}},
+	}}
+	client := newClient(api)
+
+	entries, err := client.DatasourceEntries(ctx, 9, "de")
+	require.NoError(t, err)
+	assert.Equal(t, []management.DatasourceEntry{{ID: 1, Name: "red", Value: "#f00", DimensionValue: "rot"}}, entries)
+	assert.Equal(t, "9", api.requests[0].URL.Query().Get("datasource_id"))
+
+	entry, err := client.CreateDatasourceEntry(ctx, management.DatasourceEntry{DatasourceID: 9, Name: "blue", Value: "#00f"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.ID)
+
+	entry.DimensionValue = "blau"
+	require.NoError(t, client.UpdateDatasourceEntry(ctx, entry, 3))
+	assert.JSONEq(t, `{"datasource_entry": {"id": 2, "name": "blue", "value": "#00f", "dimension_value": "blau"}, "dimension_id": 3}`, api.bodies[2])
+}
+
+func TestUploadAsset(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{responses: map[string][]response{
+		"POST " + space + "assets": {{status: 200, body: `{"id": 11, "pretty_url": "//a.storyblok.com/f/42/2x1/abc/shoe.png",
+			"post_url": "https://s3.amazonaws.com/a.storyblok.com", "fields": {"key": "f/42/2x1/abc/shoe.png", "policy": "p"}}`}},
+		"POST s3.amazonaws.com/a.storyblok.com":    {{status: 204}},
+		"GET " + space + "assets/11/finish_upload": {{status: 200, body: `{}`}},
+	}}
+	client := newClient(api)
+
+	data := &bytes.Buffer{}
+	require.NoError(t, png.Encode(data, image.NewGray(image.Rect(0, 0, 2, 1))))
+	asset, err := client.UploadAsset(ctx, "images/shoe.png", data.Bytes(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, management.Asset{ID: 11, Filename: "https://a.storyblok.com/f/42/2x1/abc/shoe.png", AssetFolderID: 3}, asset)
+	assert.JSONEq(t, `{"filename": "shoe.png", "size": "2x1", "asset_folder_id": 3}`, api.bodies[0])
+
+	upload := api.requests[1]
+	assert.Empty(t, upload.Header.Get("Authorization"), "signed upload")
+	_, params, err := mime.ParseMediaType(upload.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	form, err := multipart.NewReader(strings.NewReader(api.bodies[1]), params["boundary"]).ReadForm(1 << 20)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f/42/2x1/abc/shoe.png"}, form.Value["key"])
+	assert.Equal(t, "shoe.png", form.File["file"][0].Filename)
+	assert.Len(t, api.requests, 3)
+}
+
+func TestOAuthToken(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{responses: map[string][]response{
+		"POST app.storyblok.com/oauth/token": {{status: 200, body: `{"access_token": "access", "refresh_token": "refresh2", "expires_in": 3600}`}},
+		"GET " + space + "components":        {{status: 200, body: `{"components": []}`}},
+	}}
+	token := management.NewOAuthToken("id", "secret", "refresh", api)
+	client := management.NewClient(ctx, 42, token, api)
+	client.RateLimit = 1000
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Components(ctx)
+		require.NoError(t, err)
+	}
+	require.Len(t, api.requests, 3, "token refreshed once")
+	assert.Contains(t, api.bodies[0], "refresh_token=refresh")
+	assert.Equal(t, "refresh2", token.RefreshToken)
+	assert.Equal(t, "Bearer access", api.requests[2].Header.Get("Authorization"))
+}
@@ -0,0 +1,92 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+var _ TokenSource = &OAuthToken{}
+
+// OAuthToken authorizes a Storyblok app installed in the space. Access tokens are refreshed with the
+// refresh token of the installation shortly before they expire.
+type OAuthToken struct {
+	HttpClient   storyblok.HTTPClient
+	TokenURL     string // "https://app.storyblok.com/oauth/token"
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+
+	mu          sync.Mutex
+	accessToken string
+	expires     time.Time
+}
+
+func NewOAuthToken(clientID string, clientSecret string, refreshToken string, httpClient storyblok.HTTPClient) *OAuthToken {
+	return &OAuthToken{
+		HttpClient:   httpClient,
+		TokenURL:     "https://app.storyblok.com/oauth/token",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RefreshToken: refreshToken,
+	}
+}
+
+func (t *OAuthToken) Authorization(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.accessToken != "" && time.Now().Add(time.Minute).Before(t.expires) {
+		return "Bearer " + t.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.RefreshToken},
+		"client_id":     {t.ClientID},
+		"client_secret": {t.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", t.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("management: oauth: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+	resp, err := t.HttpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("management: oauth: resp: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("management: oauth: readBody: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("management: oauth: status: %d err: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	token := struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("management: oauth: json_unmarshal: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("management: oauth: no access_token")
+	}
+	t.accessToken = token.AccessToken
+	t.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	if token.RefreshToken != "" {
+		t.RefreshToken = token.RefreshToken
+	}
+	return "Bearer " + t.accessToken, nil
+}
@@ -0,0 +1,138 @@
+package management
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Story struct {
+	ID          int            `json:"id,omitempty"`
+	UUID        string         `json:"uuid,omitempty"`
+	Name        string         `json:"name"`
+	Slug        string         `json:"slug"`
+	FullSlug    string         `json:"full_slug,omitempty"`
+	ParentID    int            `json:"parent_id"` // folder, 0 is the root, always sent so updates can move stories there
+	IsFolder    bool           `json:"is_folder,omitempty"`
+	IsStartpage bool           `json:"is_startpage,omitempty"`
+	Position    int            `json:"position,omitempty"`
+	TagList     []string       `json:"tag_list,omitempty"`
+	Content     map[string]any `json:"content,omitempty"` // with "component"
+	Published   bool           `json:"published,omitempty"`
+	CreatedAt   *time.Time     `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time     `json:"updated_at,omitempty"`
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+}
+
+type StoriesOptions struct {
+	StartsWith string // full slug prefix, e.g. "products/"
+	WithSlug   string // exact full slug
+	Search     string // text search in names and slugs
+	Page       int    // 0 reads all pages
+	PerPage    int    // 100, the maximum
+}
+
+type storyRequest struct {
+	Story       Story  `json:"story"`
+	Publish     int    `json:"publish,omitempty"`
+	ForceUpdate string `json:"force_update,omitempty"`
+}
+
+type storyResponse struct {
+	Story Story `json:"story"`
+}
+
+// Stories lists the stories of the space without content, all pages unless opts.Page is set
+func (c *Client) Stories(ctx context.Context, opts StoriesOptions) ([]Story, error) {
+	params := url.Values{}
+	if opts.StartsWith != "" {
+		params.Set("starts_with", opts.StartsWith)
+	}
+	if opts.WithSlug != "" {
+		params.Set("with_slug", opts.WithSlug)
+	}
+	if opts.Search != "" {
+		params.Set("text_search", opts.Search)
+	}
+	perPage := opts.PerPage
+	if perPage <= 0 || perPage > 100 {
+		perPage = 100
+	}
+	params.Set("per_page", strconv.Itoa(perPage))
+
+	stories := []Story{}
+	err := pages(func(page int) (int, int, error) {
+		if opts.Page > 0 {
+			page = opts.Page
+		}
+		params.Set("page", strconv.Itoa(page))
+		resp := struct {
+			Stories []Story `json:"stories"`
+		}{}
+		header, err := c.do(ctx, "GET", "stories", params, nil, &resp)
+		if err != nil {
+			return 0, 0, err
+		}
+		stories = append(stories, resp.Stories...)
+		if opts.Page > 0 {
+			return len(resp.Stories), len(resp.Stories), nil
+		}
+		return len(resp.Stories), total(header), nil
+	})
+	return stories, err
+}
+
+// Story returns the story with id including content
+func (c *Client) Story(ctx context.Context, id int) (Story, error) {
+	resp := storyResponse{}
+	_, err := c.do(ctx, "GET", "stories/"+strconv.Itoa(id), nil, nil, &resp)
+	return resp.Story, err
+}
+
+// CreateStory creates story and publishes it when publish is set
+func (c *Client) CreateStory(ctx context.Context, story Story, publish bool) (Story, error) {
+	resp := storyResponse{}
+	_, err := c.do(ctx, "POST", "stories", nil, storyRequest{Story: story, Publish: boolInt(publish)}, &resp)
+	return resp.Story, err
+}
+
+// UpdateStory replaces the story with story.ID, also while it is opened by an editor,
+// and publishes it when publish is set
+func (c *Client) UpdateStory(ctx context.Context, story Story, publish bool) (Story, error) {
+	resp := storyResponse{}
+	_, err := c.do(ctx, "PUT", "stories/"+strconv.Itoa(story.ID), nil, storyRequest{Story: story, Publish: boolInt(publish), ForceUpdate: "1"}, &resp)
+	return resp.Story, err
+}
+
+// PublishStory publishes the story with id, in the given languages only when set
+func (c *Client) PublishStory(ctx context.Context, id int, languages ...string) error {
+	_, err := c.do(ctx, "GET", "stories/"+strconv.Itoa(id)+"/publish", langParams(languages), nil, nil)
+	return err
+}
+
+// UnpublishStory unpublishes the story with id, in the given languages only when set
+func (c *Client) UnpublishStory(ctx context.Context, id int, languages ...string) error {
+	_, err := c.do(ctx, "GET", "stories/"+strconv.Itoa(id)+"/unpublish", langParams(languages), nil, nil)
+	return err
+}
+
+func (c *Client) DeleteStory(ctx context.Context, id int) error {
+	_, err := c.do(ctx, "DELETE", "stories/"+strconv.Itoa(id), nil, nil, nil)
+	return err
+}
+
+func langParams(languages []string) url.Values {
+	if len(languages) == 0 {
+		return nil
+	}
+	return url.Values{"lang": {strings.Join(languages, ",")}}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package storyblok
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+)
+
+// PreviewCookie keeps a validated editor session for the navigation inside the Visual Editor iframe
+const PreviewCookie = "_storyblok_preview"
+
+type previewKey struct{}
+
+// WithPreview marks ctx as validated Visual Editor session, GetPage* then request the draft version
+func WithPreview(ctx context.Context) context.Context {
+	return context.WithValue(ctx, previewKey{}, true)
+}
+
+// IsPreview reports whether ctx belongs to a validated Visual Editor session
+func IsPreview(ctx context.Context) bool {
+	preview, _ := ctx.Value(previewKey{}).(bool)
+	return preview
+}
+
+// PreviewMiddleware validates the query parameters the Visual Editor adds to the preview URL:
+// _storyblok, _storyblok_tk[space_id], _storyblok_tk[timestamp] and _storyblok_tk[token],
+// the sha1 of "space_id:preview_token:timestamp". The token of the client has to be the preview token.
+// Validated requests get the preview flag in their context and a cookie that keeps the session for
+// PreviewMaxAge, all other requests pass unchanged.
+func (c *Client) PreviewMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		spaceID, timestamp, token := q.Get("_storyblok_tk[space_id]"), q.Get("_storyblok_tk[timestamp]"), q.Get("_storyblok_tk[token]")
+		if q.Has("_storyblok") && c.ValidPreviewToken(spaceID, timestamp, token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     PreviewCookie,
+				Value:    url.QueryEscape(spaceID + ":" + timestamp + ":" + token),
+				Path:     "/",
+				MaxAge:   int(c.PreviewMaxAge.Seconds()),
+				HttpOnly: true,
+				Secure:   true,
+				SameSite: http.SameSiteNoneMode, // the editor is a different site
+			})
+			next.ServeHTTP(w, r.WithContext(WithPreview(r.Context())))
+			return
+		}
+		if q.Has("_storyblok") {
+			slog.WarnContext(r.Context(), "storyblok - preview token invalid", slog.String("space_id", spaceID), slog.String("timestamp", timestamp))
+		}
+
+		if cookie, err := r.Cookie(PreviewCookie); err == nil {
+			value, _ := url.QueryUnescape(cookie.Value)
+			parts := strings.Split(value, ":")
+			if len(parts) == 3 && c.ValidPreviewToken(parts[0], parts[1], parts[2]) {
+				next.ServeHTTP(w, r.WithContext(WithPreview(r.Context())))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidPreviewToken checks the editor token and that timestamp is not older than PreviewMaxAge
+func (c *Client) ValidPreviewToken(spaceID, timestamp, token string) bool {
+	if spaceID == "" || token == "" {
+		return false
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > c.PreviewMaxAge || age < -time.Minute {
+		return false
+	}
+	sum := sha1.Sum([]byte(spaceID + ":" + c.cmsAuthToken + ":" + timestamp))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(token))) == 1
+}
+
+// requestVersion switches to the draft version for validated editor sessions
+func (c *Client) requestVersion(ctx context.Context, version string) string {
+	if IsPreview(ctx) {
+		return c.versionWhereCacheIgnored
+	}
+	return version
+}
@@ -0,0 +1,88 @@
+package storyblok_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func editorQuery(spaceID, previewToken string, at time.Time) url.Values {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	sum := sha1.Sum([]byte(spaceID + ":" + previewToken + ":" + timestamp))
+	return url.Values{
+		"_storyblok":               {"123"},
+		"_storyblok_tk[space_id]":  {spaceID},
+		"_storyblok_tk[timestamp]": {timestamp},
+		"_storyblok_tk[token]":     {hex.EncodeToString(sum[:])},
+	}
+}
+
+func TestPreviewMiddleware(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "preview_token", "empty_cache_token", &MockCache{}, &MockHTTPClient{})
+
+	var preview bool
+	handler := client.PreviewMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		preview = storyblok.IsPreview(r.Context())
+	}))
+	serve := func(query url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/home?"+query.Encode(), nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(editorQuery("42", "preview_token", time.Now()))
+	assert.True(t, preview)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, storyblok.PreviewCookie, cookies[0].Name)
+
+	serve(url.Values{}, cookies[0])
+	assert.True(t, preview, "session cookie")
+
+	serve(url.Values{})
+	assert.False(t, preview)
+
+	serve(editorQuery("42", "other_token", time.Now()))
+	assert.False(t, preview, "wrong preview token")
+
+	serve(editorQuery("42", "preview_token", time.Now().Add(-2*time.Hour)))
+	assert.False(t, preview, "expired")
+
+	serve(url.Values{}, &http.Cookie{Name: storyblok.PreviewCookie, Value: "42:1:forged"})
+	assert.False(t, preview, "forged cookie")
+}
+
+func TestPreviewRequestsDraft(t *testing.T) {
+	ctx := context.Background()
+	mockCache := &MockCache{}
+	mockHTTPClient := &MockHTTPClient{}
+	client := storyblok.NewClient(ctx, "preview_token", "empty_cache_token", mockCache, mockHTTPClient)
+
+	mockHTTPClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Query().Get("version") == "draft"
+	})).Return(httpResponse(http.StatusOK, []byte(`{"story": {"name": "draft"}}`)), nil).Once()
+
+	// no cache calls, drafts are not cached
+	page, err := client.GetPage(storyblok.WithPreview(ctx), "home", "published", "")
+	require.NoError(t, err)
+	assert.Equal(t, "draft", page["story"].(map[string]any)["name"])
+	mockHTTPClient.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
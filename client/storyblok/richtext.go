@@ -0,0 +1,169 @@
+package storyblok
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// RenderRichText renders a Storyblok rich text field ({"type": "doc", "content": [...]}) as HTML.
+// Text and attributes are escaped, links with other schemes than http, https, mailto and tel are dropped.
+// Embedded bloks are skipped, they need the templates of the site.
+func RenderRichText(doc any) string {
+	b := &strings.Builder{}
+	renderNode(b, doc)
+	return b.String()
+}
+
+var richTextNodes = map[string]string{
+	"paragraph":    "p",
+	"blockquote":   "blockquote",
+	"bullet_list":  "ul",
+	"ordered_list": "ol",
+	"list_item":    "li",
+}
+
+var richTextMarks = map[string]string{
+	"bold":        "b",
+	"italic":      "i",
+	"strike":      "s",
+	"underline":   "u",
+	"code":        "code",
+	"superscript": "sup",
+	"subscript":   "sub",
+	"highlight":   "mark",
+}
+
+func renderNode(b *strings.Builder, v any) {
+	node, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	attrs, _ := node["attrs"].(map[string]any)
+	nodeType, _ := node["type"].(string)
+
+	switch nodeType {
+	case "text":
+		renderText(b, node)
+		return
+	case "hard_break":
+		b.WriteString("<br>")
+		return
+	case "horizontal_rule":
+		b.WriteString("<hr>")
+		return
+	case "emoji":
+		emoji, _ := attrs["emoji"].(string)
+		b.WriteString(html.EscapeString(emoji))
+		return
+	case "image":
+		src, _ := attrs["src"].(string)
+		alt, _ := attrs["alt"].(string)
+		if src = safeURL(src); src != "" {
+			b.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `">`)
+		}
+		return
+	case "blok":
+		return
+	}
+
+	tag := richTextNodes[nodeType]
+	switch nodeType {
+	case "heading":
+		level, _ := attrs["level"].(float64)
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		tag = "h" + strconv.Itoa(int(level))
+	case "code_block":
+		b.WriteString("<pre><code>")
+		renderContent(b, node)
+		b.WriteString("</code></pre>")
+		return
+	}
+	if tag != "" {
+		b.WriteString("<" + tag + ">")
+	}
+	renderContent(b, node)
+	if tag != "" {
+		b.WriteString("</" + tag + ">")
+	}
+}
+
+func renderContent(b *strings.Builder, node map[string]any) {
+	content, _ := node["content"].([]any)
+	for _, child := range content {
+		renderNode(b, child)
+	}
+}
+
+func renderText(b *strings.Builder, node map[string]any) {
+	text, _ := node["text"].(string)
+	marks, _ := node["marks"].([]any)
+	closing := []string{}
+	for _, m := range marks {
+		mark, _ := m.(map[string]any)
+		markType, _ := mark["type"].(string)
+		attrs, _ := mark["attrs"].(map[string]any)
+		switch markType {
+		case "link":
+			href := linkHref(attrs)
+			if href == "" {
+				continue
+			}
+			b.WriteString(`<a href="` + html.EscapeString(href) + `"`)
+			if target, _ := attrs["target"].(string); target == "_blank" {
+				b.WriteString(` target="_blank" rel="noopener noreferrer"`)
+			}
+			b.WriteString(">")
+			closing = append(closing, "</a>")
+		case "anchor":
+			id, _ := attrs["id"].(string)
+			b.WriteString(`<span id="` + html.EscapeString(id) + `">`)
+			closing = append(closing, "</span>")
+		default:
+			if tag, ok := richTextMarks[markType]; ok {
+				b.WriteString("<" + tag + ">")
+				closing = append(closing, "</"+tag+">")
+			}
+		}
+	}
+	b.WriteString(html.EscapeString(text))
+	for i := len(closing) - 1; i >= 0; i-- {
+		b.WriteString(closing[i])
+	}
+}
+
+// linkHref builds the href of a link mark, story links are relative to the site root
+func linkHref(attrs map[string]any) string {
+	href, _ := attrs["href"].(string)
+	linktype, _ := attrs["linktype"].(string)
+	switch linktype {
+	case "email":
+		if !strings.HasPrefix(href, "mailto:") {
+			href = "mailto:" + href
+		}
+	case "story":
+		if href != "" && !strings.HasPrefix(href, "/") {
+			href = "/" + href
+		}
+	}
+	if anchor, _ := attrs["anchor"].(string); anchor != "" {
+		href += "#" + anchor
+	}
+	return safeURL(href)
+}
+
+// safeURL returns u when it is relative or uses an allowed scheme, otherwise ""
+func safeURL(u string) string {
+	u = strings.TrimSpace(u)
+	scheme, _, found := strings.Cut(u, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return u
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto", "tel":
+		return u
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package storyblok_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderRichText(t *testing.T) {
+	doc := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "doc", "content": [
+		{"type": "heading", "attrs": {"level": 3}, "content": [{"type": "text", "text": "Title <1>"}]},
+		{"type": "paragraph", "content": [
+			{"type": "text", "text": "bold", "marks": [{"type": "bold"}, {"type": "italic"}]},
+			{"type": "hard_break"},
+			{"type": "text", "text": "story", "marks": [{"type": "link", "attrs": {"href": "blog/post", "linktype": "story", "anchor": "top"}}]},
+			{"type": "text", "text": "mail", "marks": [{"type": "link", "attrs": {"href": "a@b.c", "linktype": "email"}}]},
+			{"type": "text", "text": "evil", "marks": [{"type": "link", "attrs": {"href": "javascript:alert(1)", "linktype": "url"}}]},
+			{"type": "text", "text": "ext", "marks": [{"type": "link", "attrs": {"href": "https://example.com", "linktype": "url", "target": "_blank"}}]}
+		]},
+		{"type": "bullet_list", "content": [{"type": "list_item", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "item"}]}]}]},
+		{"type": "image", "attrs": {"src": "https://a.storyblok.com/f/1/x.png", "alt": "\"x\""}},
+		{"type": "blok", "attrs": {"body": [{"component": "cta"}]}},
+		{"type": "code_block", "content": [{"type": "text", "text": "a < b"}]}
+	]}`), &doc))
+
+	assert.Equal(t, `<h3>Title &lt;1&gt;</h3>`+
+		`<p><b><i>bold</i></b><br><a href="/blog/post#top">story</a><a href="mailto:a@b.c">mail</a>evil<a href="https://example.com" target="_blank" rel="noopener noreferrer">ext</a></p>`+
+		`<ul><li><p>item</p></li></ul>`+
+		`<img src="https://a.storyblok.com/f/1/x.png" alt="&#34;x&#34;">`+
+		`<pre><code>a &lt; b</code></pre>`, storyblok.RenderRichText(doc))
+
+	assert.Equal(t, "", storyblok.RenderRichText(nil))
+	assert.Equal(t, "", storyblok.RenderRichText("plain"))
+}
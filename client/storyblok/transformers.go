@@ -0,0 +1,156 @@
+package storyblok
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"log/slog"
+)
+
+// StripEditable removes the "_editable" comments of the Visual Editor from the content,
+// use it for clients that never serve preview sessions
+func StripEditable() Transformer {
+	return ContentTransformer("strip_editable", "1", func(n Node) (map[string]any, error) {
+		delete(n.Value, "_editable")
+		return n.Value, nil
+	})
+}
+
+// RewriteAssetHosts replaces the hosts of URLs in the content, e.g. {"a.storyblok.com": "assets.example.com"}
+// for a CDN in front of the Storyblok asset store
+func RewriteAssetHosts(hosts map[string]string) Transformer {
+	from := make([]string, 0, len(hosts))
+	for host := range hosts {
+		from = append(from, host)
+	}
+	sort.Strings(from)
+	config := make([]string, 0, len(from))
+	for _, host := range from {
+		config = append(config, host+"="+hosts[host])
+	}
+
+	t := ContentTransformer("rewrite_asset_hosts", "1", func(n Node) (map[string]any, error) {
+		for field, value := range n.Value {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			n.Value[field] = rewriteHost(s, from, hosts)
+		}
+		return n.Value, nil
+	})
+	t.Config = strings.Join(config, ",")
+	return t
+}
+
+func rewriteHost(s string, from []string, hosts map[string]string) string {
+	for _, host := range from {
+		for _, scheme := range []string{"https://", "http://", "//"} {
+			if rest, ok := strings.CutPrefix(s, scheme+host+"/"); ok {
+				return scheme + hosts[host] + "/" + rest
+			}
+		}
+	}
+	return s
+}
+
+// ResolveLinks updates the URLs of story links in multilink fields and rich text to the current slugs of the
+// linked stories, the "cached_url" of multilinks is only updated when the linking story is saved.
+// The published links are kept in the cache like stories.
+func (c *Client) ResolveLinks() Transformer {
+	return Transformer{
+		Name:    "resolve_links",
+		Version: "1",
+		Func: func(ctx context.Context, resp map[string]any) error {
+			links, err := c.cachedLinks(ctx)
+			if err != nil {
+				return err
+			}
+			return ContentTransformer("", "", func(n Node) (map[string]any, error) {
+				if n.Value["linktype"] != "story" {
+					return n.Value, nil
+				}
+				uuid, _ := n.Value["id"].(string)
+				if uuid == "" {
+					// rich text link marks
+					uuid, _ = n.Value["uuid"].(string)
+				}
+				link, ok := links[uuid]
+				if !ok {
+					return n.Value, nil
+				}
+				if _, ok := n.Value["cached_url"]; ok {
+					n.Value["cached_url"] = link.Slug
+				}
+				if _, ok := n.Value["href"]; ok {
+					n.Value["href"] = "/" + link.Slug
+				}
+				return n.Value, nil
+			}).Func(ctx, resp)
+		},
+	}
+}
+
+// RefreshLinks replaces the cached links of ResolveLinks
+func (c *Client) RefreshLinks(ctx context.Context) error {
+	if c.cache != nil {
+		if err := c.cache.Del(ctx, c.CacheKey("l", "", c.versionDefault, "")); err != nil {
+			return err
+		}
+	}
+	_, err := c.cachedLinks(ctx)
+	return err
+}
+
+func (c *Client) cachedLinks(ctx context.Context) (map[string]Link, error) {
+	cacheKey := c.CacheKey("l", "", c.versionDefault, "")
+	links := map[string]Link{}
+
+	if c.cache != nil {
+		obj, err := c.cache.Get(ctx, cacheKey)
+		if err == nil && obj != nil && json.Unmarshal(obj, &links) == nil {
+			return links, nil
+		}
+	}
+
+	links, err := c.Links(ctx, c.versionDefault)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(links); err == nil && c.cache != nil {
+		if err := c.cache.Set(ctx, cacheKey, data); err != nil {
+			slog.WarnContext(ctx, "storyblok - cache.Set error", slog.String("key", cacheKey), slog.Any("err", err))
+		}
+	}
+	return links, nil
+}
+
+// InjectField sets field on every block to the result of fn, unless fn returns nil.
+// version identifies the code of fn, see Transformer.Version.
+func InjectField(field string, version string, fn func(n Node) any) Transformer {
+	return ContentTransformer("inject_field:"+field, version, func(n Node) (map[string]any, error) {
+		if !n.IsBlock() {
+			return n.Value, nil
+		}
+		if value := fn(n); value != nil {
+			n.Value[field] = value
+		}
+		return n.Value, nil
+	})
+}
+
+// pipelineHash identifies the names, versions and configurations of the transformers
+func pipelineHash(transformers []Transformer) string {
+	if len(transformers) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, t := range transformers {
+		h.Write([]byte(t.Name + "\x00" + t.Version + "\x00" + t.Config + "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
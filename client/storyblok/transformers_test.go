@@ -0,0 +1,74 @@
+package storyblok_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformers(t *testing.T) {
+	ctx := context.Background()
+	cache := memory_cache.New()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cache, warmerCDN{
+		"links": `{"links": {"u1": {"uuid": "u1", "slug": "about-us"}}}`,
+		"stories/home": `{"story": {"content": {"component": "page", "_editable": "<!--#storyblok#-->", "body": [
+			{"component": "teaser", "_editable": "<!--#storyblok#-->",
+				"image": {"id": 1, "filename": "https://a.storyblok.com/f/1/hero.png"},
+				"link": {"id": "u1", "linktype": "story", "cached_url": "about"},
+				"text": {"type": "doc", "content": [{"type": "text", "text": "about", "marks": [
+					{"type": "link", "attrs": {"href": "/about", "uuid": "u1", "linktype": "story"}}]}]}}
+		]}}}`,
+	})
+	assert.Equal(t, "j:published:en:home", client.CacheKey("j", "home", "published", "en"))
+
+	client.Use(
+		storyblok.StripEditable(),
+		storyblok.RewriteAssetHosts(map[string]string{"a.storyblok.com": "assets.example.com"}),
+		client.ResolveLinks(),
+		storyblok.InjectField("_depth", "1", func(n storyblok.Node) any {
+			return len(n.Path)
+		}),
+	)
+	key := client.CacheKey("j", "home", "published", "en")
+	assert.Regexp(t, `^j~[0-9a-f]{8}:published:en:home$`, key)
+
+	page, err := client.GetPage(ctx, "home", "published", "en")
+	require.NoError(t, err)
+	content := page["story"].(map[string]any)["content"].(map[string]any)
+	assert.NotContains(t, content, "_editable")
+	teaser := content["body"].([]any)[0].(map[string]any)
+	assert.NotContains(t, teaser, "_editable")
+	assert.Equal(t, "https://assets.example.com/f/1/hero.png", teaser["image"].(map[string]any)["filename"])
+	assert.Equal(t, "/about-us", storyblok.LinkURL(teaser["link"]))
+	assert.Contains(t, storyblok.RenderRichText(teaser["text"]), `href="/about-us"`)
+	assert.Equal(t, float64(len("body.0")), teaser["_depth"])
+	assert.NotContains(t, teaser["image"], "_depth", "not a block")
+
+	// listings are transformed as well
+	client.HttpClient = warmerCDN{"stories": `{"stories": [{"content": {"component": "page", "_editable": "<!--#storyblok#-->",
+		"image": "https://a.storyblok.com/f/1/hero.png"}}]}`}
+	stories, err := client.Stories(ctx, "published", "", nil)
+	require.NoError(t, err)
+	require.Len(t, stories, 1)
+	assert.JSONEq(t, `{"content": {"component": "page", "image": "https://assets.example.com/f/1/hero.png", "_depth": 0}}`, string(stories[0]))
+
+	cached, err := cache.Get(ctx, key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(cached), "a.storyblok.com", "cached transformed")
+
+	other := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cache, warmerCDN{})
+	other.Use(storyblok.RewriteAssetHosts(map[string]string{"a.storyblok.com": "cdn.example.com"}))
+	assert.NotEqual(t, key, other.CacheKey("j", "home", "published", "en"), "configuration is part of the key")
+
+	depth := func(n storyblok.Node) any { return len(n.Path) }
+	v1 := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cache, warmerCDN{})
+	v1.Use(storyblok.InjectField("_depth", "1", depth))
+	v2 := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cache, warmerCDN{})
+	v2.Use(storyblok.InjectField("_depth", "2", depth))
+	assert.NotEqual(t, v1.CacheKey("j", "home", "published", "en"), v2.CacheKey("j", "home", "published", "en"), "version is part of the key")
+}
@@ -0,0 +1,177 @@
+package storyblok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"log/slog"
+)
+
+// Node is an object in the content of a story: a block, an asset, a multilink or a rich text node
+type Node struct {
+	Path   string         // field names and list indexes from the content root joined by ".", e.g. "body.1.columns.0"
+	Value  map[string]any // the object
+	Parent map[string]any // the enclosing object, nil for the content root
+}
+
+// IsBlock reports whether the node is a block, blocks have a component
+func (n Node) IsBlock() bool {
+	component, _ := n.Value["component"].(string)
+	return component != ""
+}
+
+// SkipChildren is returned by a WalkFunc or TransformFunc to skip the objects below the node
+var SkipChildren = errors.New("skip children")
+
+// WalkFunc is called by Walk for every node
+type WalkFunc func(n Node) error
+
+// TransformFunc is called by Transform for every node and returns its replacement, which may be n.Value
+// after changes, or nil to delete the node
+type TransformFunc func(n Node) (map[string]any, error)
+
+// Walk calls fn for content and every object below it, parents before children and fields in name order.
+// It stops at the first error other than SkipChildren.
+func Walk(content map[string]any, fn WalkFunc) error {
+	_, err := Transform(content, func(n Node) (map[string]any, error) {
+		return n.Value, fn(n)
+	})
+	return err
+}
+
+// Transform calls fn for content and every object below it like Walk and replaces the objects by the results,
+// then continues with the objects below the replacement. Deleted list elements are removed from the list and
+// deleted objects in fields are removed from their parent. content is changed in place, the returned content
+// is nil when the root was deleted.
+func Transform(content map[string]any, fn TransformFunc) (map[string]any, error) {
+	return transform(content, "", nil, fn)
+}
+
+func transform(value map[string]any, path string, parent map[string]any, fn TransformFunc) (map[string]any, error) {
+	value, err := fn(Node{Path: path, Value: value, Parent: parent})
+	if errors.Is(err, SkipChildren) {
+		return value, nil
+	}
+	if err != nil || value == nil {
+		return value, err
+	}
+	for _, field := range sortedFields(value) {
+		fieldPath := joinPath(path, field)
+		switch v := value[field].(type) {
+		case map[string]any:
+			child, err := transform(v, fieldPath, value, fn)
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				delete(value, field)
+			} else {
+				value[field] = child
+			}
+		case []any:
+			kept := v[:0]
+			for i, elem := range v {
+				obj, ok := elem.(map[string]any)
+				if !ok {
+					kept = append(kept, elem)
+					continue
+				}
+				child, err := transform(obj, joinPath(fieldPath, strconv.Itoa(i)), value, fn)
+				if err != nil {
+					return nil, err
+				}
+				if child != nil {
+					kept = append(kept, child)
+				}
+			}
+			value[field] = kept
+		}
+	}
+	return value, nil
+}
+
+func joinPath(path string, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "." + elem
+}
+
+// Transformer changes the responses fetched from the CMS before they are cached, see Client.Use
+type Transformer struct {
+	Name string // identifies the transformer in errors and, with Version and Config, in the cache keys
+	// Version identifies the code of Func and is required, change it whenever Func changes
+	// so content transformed by the previous code is not served from the cache
+	Version string
+	Config  string // identifies the configuration, e.g. the hosts of RewriteAssetHosts
+	// Func changes the decoded response in place, a story response has "story", a listing "stories"
+	Func func(ctx context.Context, resp map[string]any) error
+}
+
+// ContentTransformer returns a Transformer applying fn to the content of the story, or of every story of a listing.
+// version identifies the code of fn, see Transformer.Version.
+func ContentTransformer(name string, version string, fn TransformFunc) Transformer {
+	return Transformer{
+		Name:    name,
+		Version: version,
+		Func: func(ctx context.Context, resp map[string]any) error {
+			stories, _ := resp["stories"].([]any)
+			if story, ok := resp["story"]; ok {
+				stories = append(stories, story)
+			}
+			for _, s := range stories {
+				story, ok := s.(map[string]any)
+				if !ok {
+					continue
+				}
+				content, ok := story["content"].(map[string]any)
+				if !ok {
+					continue
+				}
+				content, err := Transform(content, fn)
+				if err != nil {
+					return err
+				}
+				story["content"] = content
+			}
+			return nil
+		},
+	}
+}
+
+// Use registers transformers that run in order on every response fetched from the CMS, before it is cached,
+// so GetPage, GetPageAsSimpleBlocksWithID and everything built on them see the transformed content.
+// The names, versions and configurations of the transformers are part of the cache keys, transformers
+// without a version are rejected like invalid client configurations.
+// Use is not safe for concurrent use with requests, register the transformers while setting up the client.
+func (c *Client) Use(transformers ...Transformer) {
+	for _, t := range transformers {
+		if t.Version == "" {
+			slog.Error("storyblok - Transformer.Version is empty", slog.String("transformer", t.Name))
+			os.Exit(1)
+		}
+	}
+	c.transformers = append(c.transformers, transformers...)
+	c.pipelineHash = pipelineHash(c.transformers)
+}
+
+// transform runs the transformers on a fetched response
+func (c *Client) transform(ctx context.Context, body []byte) ([]byte, error) {
+	if len(c.transformers) == 0 {
+		return body, nil
+	}
+	resp := map[string]any{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("json_unmarshal: %w", err)
+	}
+	for _, t := range c.transformers {
+		if err := t.Func(ctx, resp); err != nil {
+			return nil, fmt.Errorf("transformer %s: %w", t.Name, err)
+		}
+	}
+	return json.Marshal(resp)
+}
@@ -0,0 +1,103 @@
+package storyblok_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nestedContent(t *testing.T) map[string]any {
+	page := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(nestedStory), &page))
+	return page["story"].(map[string]any)["content"].(map[string]any)
+}
+
+func TestWalk(t *testing.T) {
+	content := nestedContent(t)
+
+	var paths []string
+	parents := map[string]any{}
+	err := storyblok.Walk(content, func(n storyblok.Node) error {
+		paths = append(paths, n.Path)
+		if n.Parent != nil {
+			parents[n.Path] = n.Parent["component"]
+		}
+		if n.Value["id"] == "pricing" {
+			return storyblok.SkipChildren
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "body.0", "body.1", "link", "sidebar.0"}, paths)
+	assert.Equal(t, "page", parents["body.1"])
+
+	stop := errors.New("stop")
+	err = storyblok.Walk(content, func(n storyblok.Node) error {
+		if n.Path == "body.1.columns.0" {
+			assert.True(t, n.IsBlock())
+			assert.Equal(t, "grid", n.Parent["component"])
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+}
+
+func TestTransform(t *testing.T) {
+	content := nestedContent(t)
+
+	content, err := storyblok.Transform(content, func(n storyblok.Node) (map[string]any, error) {
+		switch {
+		case n.Value["id"] == "headline":
+			return nil, nil
+		case n.Value["id"] == "footnote":
+			return map[string]any{"component": "note", "items": []any{map[string]any{"component": "text", "id": "inner"}}}, nil
+		case n.Value["linktype"] == "story":
+			return nil, nil
+		case n.Value["id"] == "inner":
+			n.Value["text"] = "replacements are transformed"
+		}
+		return n.Value, nil
+	})
+	require.NoError(t, err)
+
+	body := content["body"].([]any)
+	require.Len(t, body, 1)
+	columns := body[0].(map[string]any)["columns"].([]any)
+	assert.Equal(t, "note", columns[1].(map[string]any)["component"])
+	assert.Equal(t, "replacements are transformed", columns[1].(map[string]any)["items"].([]any)[0].(map[string]any)["text"])
+	assert.NotContains(t, content, "link")
+}
+
+func TestClientUse(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), languageCDN{"": nestedStory})
+	calls := 0
+	client.Use(storyblok.ContentTransformer("drop_sidebar", "1", func(n storyblok.Node) (map[string]any, error) {
+		if n.Path == "" {
+			calls++
+			delete(n.Value, "sidebar")
+		}
+		return n.Value, nil
+	}))
+
+	for i := 0; i < 2; i++ {
+		page, err := client.GetPage(ctx, "home", "published", "")
+		require.NoError(t, err)
+		assert.NotContains(t, page["story"].(map[string]any)["content"], "sidebar")
+	}
+	assert.Equal(t, 1, calls, "transformed before caching")
+
+	client.Use(storyblok.Transformer{Name: "fail", Version: "1", Func: func(ctx context.Context, resp map[string]any) error {
+		return errors.New("failed")
+	}})
+	_, err := client.GetPage(ctx, "home", "draft", "")
+	require.ErrorContains(t, err, "transformer fail: failed")
+}
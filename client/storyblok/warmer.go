@@ -0,0 +1,191 @@
+package storyblok
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"log/slog"
+)
+
+// Representation selects which of the cached forms of a story are pre-fetched
+type Representation int
+
+const (
+	RepresentationJSON         Representation = 1 << iota // GetPageAsJSON
+	RepresentationMap                                     // GetPage
+	RepresentationSimpleBlocks                            // GetPageAsSimpleBlocksWithID
+
+	RepresentationAll = RepresentationJSON | RepresentationMap | RepresentationSimpleBlocks
+)
+
+type WarmOptions struct {
+	Versions        []string       // ["published"], draft is never cached and skipped
+	Languages       []string       // nil: the default language and all language codes of the space
+	Representations Representation // RepresentationAll
+	Concurrency     int            // 4
+
+	// OnProgress is called after every story, from multiple goroutines. Errors are logged when nil.
+	OnProgress func(ctx context.Context, p WarmProgress)
+}
+
+type WarmProgress struct {
+	Done     int
+	Total    int
+	Page     string
+	Version  string
+	Language string
+	Err      error
+}
+
+type WarmStats struct {
+	Total    int
+	Failed   int
+	Duration time.Duration
+}
+
+type warmJob struct {
+	page, version, language string
+}
+
+// Warm pre-fetches every story of the space into the cache. Failing stories do not stop the run,
+// their errors are joined into the returned error.
+func (c *Client) Warm(ctx context.Context, opts WarmOptions) (WarmStats, error) {
+	start := time.Now()
+	stats := WarmStats{}
+	if len(opts.Versions) == 0 {
+		opts.Versions = []string{c.versionDefault}
+	}
+	if opts.Representations == 0 {
+		opts.Representations = RepresentationAll
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Languages == nil {
+		space, err := c.Space(ctx)
+		if err != nil {
+			return stats, fmt.Errorf("headless_cms: warm: %w", err)
+		}
+		opts.Languages = append([]string{""}, space.LanguageCodes...)
+	}
+
+	jobs := []warmJob{}
+	for _, version := range opts.Versions {
+		if version == c.versionWhereCacheIgnored {
+			slog.WarnContext(ctx, "storyblok - warm: version is not cached, skipped", slog.String("version", version))
+			continue
+		}
+		links, err := c.Links(ctx, version)
+		if err != nil {
+			return stats, fmt.Errorf("headless_cms: warm: %w", err)
+		}
+		for _, link := range links {
+			if link.IsFolder {
+				continue
+			}
+			for _, language := range opts.Languages {
+				jobs = append(jobs, warmJob{page: link.Slug, version: version, language: language})
+			}
+		}
+	}
+	stats.Total = len(jobs)
+
+	var (
+		done   atomic.Int64
+		mu     sync.Mutex
+		errs   []error
+		wg     sync.WaitGroup
+		tokens = make(chan struct{}, opts.Concurrency)
+	)
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		tokens <- struct{}{}
+		wg.Add(1)
+		go func(job warmJob) {
+			defer func() { <-tokens; wg.Done() }()
+			err := c.warmPage(ctx, job, opts.Representations)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			p := WarmProgress{Done: int(done.Add(1)), Total: stats.Total, Page: job.page, Version: job.version, Language: job.language, Err: err}
+			if opts.OnProgress != nil {
+				opts.OnProgress(ctx, p)
+			} else if err != nil {
+				slog.WarnContext(ctx, "storyblok - warm", slog.String("page", job.page), slog.String("language", job.language), slog.Any("err", err))
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	stats.Failed = len(errs)
+	stats.Duration = time.Since(start)
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+	return stats, errors.Join(errs...)
+}
+
+func (c *Client) warmPage(ctx context.Context, job warmJob, representations Representation) error {
+	// GetPage and GetPageAsSimpleBlocksWithID fetch the JSON representation on their way
+	if representations&RepresentationJSON != 0 && representations&(RepresentationMap|RepresentationSimpleBlocks) == 0 {
+		if _, err := c.GetPageAsJSON(ctx, job.page, job.version, job.language); err != nil {
+			return err
+		}
+	}
+	if representations&RepresentationMap != 0 {
+		if _, err := c.GetPage(ctx, job.page, job.version, job.language); err != nil {
+			return err
+		}
+	}
+	if representations&RepresentationSimpleBlocks != 0 {
+		if _, err := c.GetPageAsSimpleBlocksWithID(ctx, job.page, job.version, job.language); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartWarming warms the cache in the background now and again after every purge by EmptyCache
+// or by an InvalidateEmpty from the invalidation bus. A purge during a run restarts it.
+// The warming stops when ctx is done.
+func (c *Client) StartWarming(ctx context.Context, opts WarmOptions) {
+	c.warmMu.Lock()
+	c.warmCtx = ctx
+	c.warmOpts = &opts
+	c.warmMu.Unlock()
+	c.rewarm()
+}
+
+func (c *Client) rewarm() {
+	c.warmMu.Lock()
+	defer c.warmMu.Unlock()
+	if c.warmOpts == nil || c.warmCtx.Err() != nil {
+		return
+	}
+	if c.warmCancel != nil {
+		c.warmCancel()
+	}
+	ctx, cancel := context.WithCancel(c.warmCtx)
+	c.warmCancel = cancel
+	opts := *c.warmOpts
+	go func() {
+		defer cancel()
+		stats, err := c.Warm(ctx, opts)
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		slog.InfoContext(ctx, "storyblok - warm finished",
+			slog.Int("total", stats.Total),
+			slog.Int("failed", stats.Failed),
+			slog.Duration("duration", stats.Duration),
+			slog.Any("err", err))
+	}()
+}
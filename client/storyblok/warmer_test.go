@@ -0,0 +1,96 @@
+package storyblok_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warmerCDN answers every request with a fresh body, the mocked responses could only be read once
+type warmerCDN map[string]string
+
+func (f warmerCDN) Do(req *http.Request) (*http.Response, error) {
+	body, ok := f[strings.TrimPrefix(req.URL.Path, "/v2/cdn/")]
+	if !ok {
+		return httpResponse(http.StatusNotFound, nil), nil
+	}
+	return httpResponse(http.StatusOK, []byte(body)), nil
+}
+
+func warmerHTTPClient() warmerCDN {
+	return warmerCDN{
+		"spaces/me": `{"space": {"language_codes": ["de"]}}`,
+		"links": `{"links": {
+			"u1": {"slug": "home"},
+			"u2": {"slug": "blog", "is_folder": true},
+			"u3": {"slug": "blog/broken"}
+		}}`,
+		"stories/home": `{"story": {"content": {"body": [{"id": "headline"}]}}}`,
+	}
+}
+
+func TestWarm(t *testing.T) {
+	ctx := context.Background()
+	cache := memory_cache.New()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cache, warmerHTTPClient())
+
+	var progress atomic.Int64
+	stats, err := client.Warm(ctx, storyblok.WarmOptions{
+		Concurrency: 2,
+		OnProgress: func(ctx context.Context, p storyblok.WarmProgress) {
+			progress.Add(1)
+			assert.Equal(t, 4, p.Total)
+			assert.Equal(t, strings.HasSuffix(p.Page, "broken"), p.Err != nil)
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, 2, stats.Failed)
+	assert.Equal(t, int64(4), progress.Load())
+
+	for _, language := range []string{"", "de"} {
+		for _, prefix := range []string{"j", "r", "i"} {
+			obj, err := cache.Get(ctx, client.CacheKey(prefix, "home", "published", language))
+			require.NoError(t, err)
+			assert.NotNil(t, obj, prefix+language)
+		}
+	}
+}
+
+func TestWarmSkipsDraft(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), warmerHTTPClient())
+
+	stats, err := client.Warm(ctx, storyblok.WarmOptions{Versions: []string{"draft"}, Languages: []string{""}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Total)
+}
+
+func TestStartWarmingAfterEmptyCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := memory_cache.New()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", cache, warmerHTTPClient())
+	key := client.CacheKey("i", "home", "published", "")
+
+	warmed := func() bool {
+		obj, _ := cache.Get(ctx, key)
+		return obj != nil
+	}
+
+	client.StartWarming(ctx, storyblok.WarmOptions{Languages: []string{""}, Representations: storyblok.RepresentationSimpleBlocks})
+	require.Eventually(t, warmed, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.EmptyCache(ctx, "empty_cache_token"))
+	require.Eventually(t, warmed, time.Second, 10*time.Millisecond)
+}
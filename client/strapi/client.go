@@ -0,0 +1,252 @@
+package strapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/internal/restclient"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var _ headless_cms.Client = &Client{}
+
+// Client reads entries of one collection type from a self-hosted Strapi REST API.
+// The "draft" version is read with publicationState=preview and never cached.
+type Client struct {
+	HttpClient HTTPClient
+
+	Collection string // "pages", the plural API ID
+	SlugField  string // "slug"
+	// Populate is passed as populate parameter, e.g. "*" for one level or "deep" with the deep populate plugin
+	Populate string // "*"
+	// BodyField is the dynamic zone indexed by GetPageAsSimpleBlocksWithID.
+	// Components carry a numeric Strapi "id", so blocks are indexed by the text field BlockIDField.
+	BodyField    string // "body"
+	BlockIDField string // "block_id"
+
+	cache                 headless_cms.Cache
+	cacheEmptyActionToken string
+
+	cmsAuthToken string
+	cmsAPIUrl    string
+
+	versionDefault           string // "published"
+	versionWhereCacheIgnored string // "draft"
+}
+
+// NewClient for the Strapi instance at apiURL, e.g. "https://cms.example.com"
+func NewClient(ctx context.Context, apiURL string, token string, emptyCacheToken string, cache headless_cms.Cache, httpClient HTTPClient) *Client {
+	if apiURL == "" {
+		slog.ErrorContext(ctx, "strapi - APIUrl is empty")
+		os.Exit(1)
+	}
+	if token == "" {
+		slog.ErrorContext(ctx, "strapi - AuthToken is empty")
+		os.Exit(1)
+	}
+	if emptyCacheToken == "" {
+		slog.ErrorContext(ctx, "strapi - EmptyCacheToken is empty")
+		os.Exit(1)
+	}
+	if cache == nil {
+		slog.ErrorContext(ctx, "strapi - Cache is nil")
+		os.Exit(1)
+	}
+	return &Client{
+		cache:                    cache,
+		cacheEmptyActionToken:    emptyCacheToken,
+		versionWhereCacheIgnored: "draft",
+
+		HttpClient:   httpClient,
+		Collection:   "pages",
+		SlugField:    "slug",
+		Populate:     "*",
+		BodyField:    "body",
+		BlockIDField: "block_id",
+
+		cmsAPIUrl:      strings.TrimSuffix(apiURL, "/") + "/api/",
+		cmsAuthToken:   token,
+		versionDefault: "published",
+	}
+}
+
+func (c *Client) AuthToken() string {
+	return c.cmsAuthToken
+}
+
+func (c *Client) Cache() headless_cms.Cache {
+	return c.cache
+}
+
+func (c *Client) EmptyCache(ctx context.Context, token string) error {
+	if token != c.cacheEmptyActionToken {
+		return errors.New("token incorrect")
+	}
+	return c.cache.Empty(ctx)
+}
+
+func (c *Client) EmptyCacheToken(ctx context.Context) (string, error) {
+	if c.cacheEmptyActionToken == "" {
+		return "", errors.New("token not set")
+	}
+	return c.cacheEmptyActionToken, nil
+}
+
+// GetPageAsJSON returns the raw collection response for the entry with the slug, or "" for all entries
+func (c *Client) GetPageAsJSON(ctx context.Context, page string, version string, language string) ([]byte, error) {
+	return restclient.Bytes(ctx, c.cache, "strapi", c.CacheKey("j", page, version, language), version != c.versionWhereCacheIgnored, func() ([]byte, error) {
+		reqURL := c.cmsAPIUrl + url.PathEscape(c.Collection) + "?" + c.cmsURLParams(page, version, language)
+		return restclient.Get(ctx, c.HttpClient, reqURL, c.cmsAuthToken, strapiStatusDescriptions)
+	})
+}
+
+// GetPage returns {"entry": entry} for a slug and {"entries": [entry, ...]} for "",
+// with the data/attributes envelopes flattened to {"id": 1, ...attributes}
+func (c *Client) GetPage(ctx context.Context, page string, version string, language string) (map[string]any, error) {
+	cacheKey := c.CacheKey("r", page, version, language)
+	return restclient.JSON(ctx, c.cache, "strapi", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]any, error) {
+		entries, err := c.normalizedEntries(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+		if page == "" {
+			return map[string]any{"entries": entries}, nil
+		}
+		return map[string]any{"entry": entries[0]}, nil
+	})
+}
+
+// GetPageAsSimpleBlocksWithID indexes the components in BodyField by their BlockIDField
+func (c *Client) GetPageAsSimpleBlocksWithID(ctx context.Context, page string, version string, language string) (map[string]map[string]any, error) {
+	cacheKey := c.CacheKey("i", page, version, language)
+	return restclient.JSON(ctx, c.cache, "strapi", cacheKey, version != c.versionWhereCacheIgnored, func() (map[string]map[string]any, error) {
+		entries, err := c.normalizedEntries(ctx, page, version, language)
+		if err != nil {
+			return nil, fmt.Errorf("headless_cms: %s: %w", cacheKey, err)
+		}
+
+		resp := map[string]map[string]any{}
+		blocks, _ := entries[0][c.BodyField].([]any)
+		for _, b := range blocks {
+			block, ok := b.(map[string]any)
+			if !ok {
+				continue
+			}
+			id, ok := block[c.BlockIDField].(string)
+			if ok && len(id) > 0 {
+				resp[id] = block
+			}
+		}
+		return resp, nil
+	})
+}
+
+func (c *Client) CacheKey(prefix, page, version, language string) string {
+	return fmt.Sprint(prefix, ":", version, ":", language, ":", page)
+}
+
+// normalizedEntries returns the flattened entries of the response, at least one
+func (c *Client) normalizedEntries(ctx context.Context, page string, version string, language string) ([]map[string]any, error) {
+	jsonResp, err := c.GetPageAsJSON(ctx, page, version, language)
+	if err != nil {
+		return nil, fmt.Errorf("request_json: %w", err)
+	}
+	cmsData := struct {
+		Data []any `json:"data"`
+	}{}
+	err = json.Unmarshal(jsonResp, &cmsData)
+	if err != nil {
+		return nil, fmt.Errorf("json_unmarshal: %w", err)
+	}
+	if len(cmsData.Data) == 0 {
+		return nil, fmt.Errorf("status: %d err: %w", http.StatusNotFound, errors.New(strapiStatusDescriptions[http.StatusNotFound]))
+	}
+
+	entries := make([]map[string]any, 0, len(cmsData.Data))
+	for _, d := range cmsData.Data {
+		entry, ok := Normalize(d).(map[string]any)
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Normalize flattens the Strapi v4 envelopes: {"id": 1, "attributes": {...}} becomes {"id": 1, ...}
+// and relations {"data": x} become x, so templates can access fields directly.
+func Normalize(node any) any {
+	switch n := node.(type) {
+	case map[string]any:
+		if data, ok := n["data"]; ok && len(n) <= 2 {
+			// relation or media envelope, optionally with meta
+			if _, hasMeta := n["meta"]; len(n) == 1 || hasMeta {
+				return Normalize(data)
+			}
+		}
+		out := make(map[string]any, len(n))
+		for k, v := range n {
+			if k == "attributes" {
+				continue
+			}
+			out[k] = Normalize(v)
+		}
+		if attributes, ok := n["attributes"].(map[string]any); ok {
+			for k, v := range attributes {
+				out[k] = Normalize(v)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(n))
+		for i, v := range n {
+			out[i] = Normalize(v)
+		}
+		return out
+	}
+	return node
+}
+
+func (c *Client) cmsURLParams(page, version, language string) string {
+	if version == "" {
+		version = c.versionDefault
+	}
+	params := url.Values{}
+	if page != "" {
+		params.Set("filters["+c.SlugField+"][$eq]", page)
+	}
+	if c.Populate != "" {
+		params.Set("populate", c.Populate)
+	}
+	if language != "" {
+		params.Set("locale", language)
+	}
+	if version == c.versionWhereCacheIgnored {
+		params.Set("publicationState", "preview")
+	}
+	return params.Encode()
+}
+
+// https://docs.strapi.io/dev-docs/error-handling
+var strapiStatusDescriptions = map[int]string{
+	200: "OK Everything worked as expected.",
+	400: "Bad Request Invalid filters, populate or locale parameter.",
+	401: "Unauthorized No valid API token provided.",
+	403: "Forbidden The API token or public role has no permission for the collection.",
+	404: "Not Found The collection or entry doesn't exist (perhaps due to not yet published content entries).",
+	429: "Too Many Requests The rate limit of the instance was hit.",
+	500: "Internal Server Error Something went wrong in the Strapi instance.",
+	502: "Bad Gateway The Strapi instance could not be reached.",
+	503: "Service Unavailable The Strapi instance is unavailable.",
+}
@@ -0,0 +1,122 @@
+package strapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/internal/clienttest"
+	"github.com/dryaf/headless_cms/client/strapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const collectionResponse = `{
+	"data": [{
+		"id": 1,
+		"attributes": {
+			"slug": "login",
+			"title": "Login",
+			"cover": {"data": {"id": 7, "attributes": {"url": "/uploads/cover.png"}}},
+			"tags": {"data": [{"id": 3, "attributes": {"name": "auth"}}]},
+			"body": [
+				{"__component": "blocks.text", "id": 11, "block_id": "headline", "text": "Welcome"},
+				{"__component": "blocks.text", "id": 12, "text": "no block id"}
+			]
+		}
+	}],
+	"meta": {"pagination": {"page": 1, "pageSize": 25, "pageCount": 1, "total": 1}}
+}`
+
+func newTestServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	return clienttest.NewServer(t, requests, func(r *http.Request) string {
+		if r.URL.Query().Get("filters[slug][$eq]") == "unknown" {
+			return `{"data": [], "meta": {}}`
+		}
+		return collectionResponse
+	}, "api_token")
+}
+
+func TestGetPageAsJSON(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := newTestServer(t, &requests)
+	client := strapi.NewClient(ctx, server.URL+"/", "api_token", "empty_cache_token", memory_cache.New(), server.Client())
+
+	resp, err := client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.JSONEq(t, collectionResponse, string(resp))
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/api/pages", requests[0].URL.Path)
+	assert.Equal(t, "login", requests[0].URL.Query().Get("filters[slug][$eq]"))
+	assert.Equal(t, "*", requests[0].URL.Query().Get("populate"))
+	assert.Equal(t, "en", requests[0].URL.Query().Get("locale"))
+	assert.Equal(t, "", requests[0].URL.Query().Get("publicationState"))
+
+	// cached
+	_, err = client.GetPageAsJSON(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// draft bypasses the cache
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "en")
+	require.NoError(t, err)
+	_, err = client.GetPageAsJSON(ctx, "login", "draft", "en")
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "preview", requests[2].URL.Query().Get("publicationState"))
+}
+
+func TestGetPage(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := newTestServer(t, &requests)
+	client := strapi.NewClient(ctx, server.URL, "api_token", "empty_cache_token", memory_cache.New(), server.Client())
+
+	resp, err := client.GetPage(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	entry := resp["entry"].(map[string]any)
+	assert.Equal(t, float64(1), entry["id"])
+	assert.Equal(t, "Login", entry["title"])
+	assert.Equal(t, map[string]any{"id": float64(7), "url": "/uploads/cover.png"}, entry["cover"])
+	assert.Equal(t, []any{map[string]any{"id": float64(3), "name": "auth"}}, entry["tags"])
+
+	cached, err := client.GetPage(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Equal(t, resp, cached)
+	assert.Len(t, requests, 1)
+
+	_, err = client.GetPage(ctx, "unknown", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	all, err := client.GetPage(ctx, "", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, all["entries"], 1)
+}
+
+func TestGetPageAsSimpleBlocksWithID(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := newTestServer(t, &requests)
+	client := strapi.NewClient(ctx, server.URL, "api_token", "empty_cache_token", memory_cache.New(), server.Client())
+
+	resp, err := client.GetPageAsSimpleBlocksWithID(ctx, "login", "published", "en")
+	require.NoError(t, err)
+	assert.Len(t, resp, 1)
+	assert.Equal(t, "Welcome", resp["headline"]["text"])
+}
+
+func TestErrorStatus(t *testing.T) {
+	ctx := context.Background()
+	requests := []*http.Request{}
+	server := newTestServer(t, &requests)
+	client := strapi.NewClient(ctx, server.URL, "wrong_token", "empty_cache_token", memory_cache.New(), server.Client())
+
+	_, err := client.GetPage(ctx, "login", "published", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
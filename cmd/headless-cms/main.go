@@ -0,0 +1,129 @@
+// Command headless-cms exports Storyblok spaces into snapshots and imports snapshots into caches.
+//
+//	headless-cms export [-version published] [-out snapshot-<space>-<time>]   (needs STORYBLOK_TOKEN)
+//	headless-cms import -in <snapshot> -cache redis|file|bolt [-cache-path <path>]   (redis uses REDIS_ADDR, REDIS_PASSWORD, REDIS_MASTER_NAME)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/cache/bolt_cache"
+	"github.com/dryaf/headless_cms/cache/file_cache"
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/cache/redis_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+	"github.com/dryaf/headless_cms/snapshot"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+)
+
+func loadEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: headless-cms export|import [flags]")
+	os.Exit(2)
+}
+
+func main() {
+	// .env is optional, the environment may be set otherwise
+	_ = godotenv.Load()
+	if len(os.Args) < 2 {
+		usage()
+	}
+	if err := run(context.Background(), os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run returns the errors of the commands, so their deferred cleanups run before main exits
+func run(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "export":
+		return exportCmd(ctx, args)
+	case "import":
+		return importCmd(ctx, args)
+	}
+	usage()
+	return nil
+}
+
+func exportCmd(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	version := flags.String("version", "published", "story version to export, published or draft")
+	out := flags.String("out", "", "snapshot directory, or tarball when ending in .tar.gz (default snapshot-<space>-<time>)")
+	flags.Parse(args)
+
+	token := loadEnv("STORYBLOK_TOKEN", "")
+	client := storyblok.NewClient(ctx, token, "export", memory_cache.New(), &http.Client{Timeout: time.Minute})
+
+	if *out == "" {
+		space, err := client.Space(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get space: %w", err)
+		}
+		*out = fmt.Sprintf("snapshot-%d-%s", space.ID, time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	manifest, err := snapshot.Export(ctx, client, *version, *out)
+	if err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	fmt.Printf("Exported %d stories in %d languages to %s\n", manifest.Stories, len(manifest.Languages), *out)
+	return nil
+}
+
+func importCmd(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	in := flags.String("in", "", "snapshot directory or tarball")
+	cacheType := flags.String("cache", "redis", "cache to fill: redis, file or bolt")
+	cachePath := flags.String("cache-path", "", "directory of the file cache or database file of the bolt cache")
+	flags.Parse(args)
+	if *in == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var cache headless_cms.Cache
+	switch *cacheType {
+	case "redis":
+		cache = redis_cache.New(redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs:      []string{loadEnv("REDIS_ADDR", "localhost:6379")},
+			Password:   loadEnv("REDIS_PASSWORD", ""),
+			MasterName: loadEnv("REDIS_MASTER_NAME", ""),
+		}))
+	case "file":
+		fc, err := file_cache.New(*cachePath, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open file cache: %w", err)
+		}
+		cache = fc
+	case "bolt":
+		bc, err := bolt_cache.New(*cachePath, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open bolt cache: %w", err)
+		}
+		defer bc.Close()
+		cache = bc
+	default:
+		return fmt.Errorf("unknown cache %q", *cacheType)
+	}
+
+	count, err := snapshot.Import(ctx, *in, cache)
+	if err != nil {
+		return fmt.Errorf("failed to import: %w", err)
+	}
+	fmt.Printf("Imported %d stories from %s\n", count, *in)
+	return nil
+}
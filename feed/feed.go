@@ -0,0 +1,447 @@
+// Package feed builds RSS 2.0, Atom and JSON Feed documents from the stories of a Storyblok folder.
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+type Format string
+
+const (
+	RSS      Format = "rss"
+	Atom     Format = "atom"
+	JSONFeed Format = "json"
+)
+
+var contentTypes = map[Format]string{
+	RSS:      "application/rss+xml; charset=utf-8",
+	Atom:     "application/atom+xml; charset=utf-8",
+	JSONFeed: "application/feed+json; charset=utf-8",
+}
+
+// Fields maps the feed item fields to fields of the story content
+type Fields struct {
+	Title   string // "title", falls back to the story name
+	Summary string // "summary"
+	Body    string // "body", rich text
+	Image   string // "image", asset or URL
+	Author  string // "author", text or object with a name
+}
+
+type Options struct {
+	StartsWith  string // folder, e.g. "blog/"
+	BaseURL     string // "https://example.com", item links are BaseURL/full_slug
+	FeedURL     string // URL the feed is served at, optional
+	Title       string
+	Description string
+	Version     string // "published"
+	Language    string
+	Limit       int // 20
+	Fields      Fields
+}
+
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	FeedURL     string
+	Language    string
+	Updated     time.Time
+	Items       []Item
+	Tags        []string // cache tags of the listed stories, see storyblok.StoryTags
+}
+
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Content   string // HTML
+	Image     string
+	Author    string
+	Published time.Time
+	Updated   time.Time
+}
+
+func (o *Options) defaults() {
+	if o.Version == "" {
+		o.Version = "published"
+	}
+	if o.Limit <= 0 {
+		o.Limit = 20
+	}
+	if o.Fields == (Fields{}) {
+		o.Fields = Fields{Title: "title", Summary: "summary", Body: "body", Image: "image", Author: "author"}
+	}
+	o.BaseURL = strings.TrimSuffix(o.BaseURL, "/")
+}
+
+// Build lists the stories of the folder, newest first by first_published_at
+func Build(ctx context.Context, client *storyblok.Client, opts Options) (*Feed, error) {
+	opts.defaults()
+	params := url.Values{"starts_with": []string{opts.StartsWith}, "sort_by": []string{"first_published_at:desc"}}
+	raw, err := client.Stories(ctx, opts.Version, opts.Language, params)
+	if err != nil {
+		return nil, fmt.Errorf("feed: %w", err)
+	}
+
+	type story struct {
+		storyblok.Story
+		Content map[string]any `json:"content"`
+	}
+	stories := []story{}
+	for _, r := range raw {
+		s := story{}
+		if err := json.Unmarshal(r, &s); err != nil {
+			return nil, fmt.Errorf("feed: story: %w", err)
+		}
+		if s.FirstPublishedAt.IsZero() {
+			s.FirstPublishedAt = s.PublishedAt
+		}
+		stories = append(stories, s)
+	}
+	sort.SliceStable(stories, func(i, j int) bool {
+		return stories[i].FirstPublishedAt.After(stories[j].FirstPublishedAt)
+	})
+	if len(stories) > opts.Limit {
+		stories = stories[:opts.Limit]
+	}
+
+	f := &Feed{
+		Title:       opts.Title,
+		Description: opts.Description,
+		Link:        opts.BaseURL + "/" + strings.Trim(opts.StartsWith, "/"),
+		FeedURL:     opts.FeedURL,
+		Language:    opts.Language,
+	}
+	for _, s := range stories {
+		f.Tags = append(f.Tags, storyblok.StoryTags(s.ID, s.TagList)...)
+		item := Item{
+			ID:        s.UUID,
+			Title:     text(s.Content[opts.Fields.Title]),
+			Link:      opts.BaseURL + "/" + strings.Trim(s.FullSlug, "/"),
+			Summary:   text(s.Content[opts.Fields.Summary]),
+			Image:     asset(s.Content[opts.Fields.Image]),
+			Author:    author(s.Content[opts.Fields.Author]),
+			Published: s.FirstPublishedAt,
+			Updated:   s.PublishedAt,
+		}
+		if item.ID == "" {
+			item.ID = item.Link
+		}
+		if item.Title == "" {
+			item.Title = s.Name
+		}
+		if body := s.Content[opts.Fields.Body]; body != nil {
+			if b, ok := body.(string); ok {
+				item.Content = b
+			} else {
+				item.Content = storyblok.RenderRichText(body)
+			}
+		}
+		if item.Updated.Before(item.Published) {
+			item.Updated = item.Published
+		}
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f, nil
+}
+
+func text(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+// asset reads asset fields {"filename": "https://..."} and plain URLs
+func asset(v any) string {
+	if m, ok := v.(map[string]any); ok {
+		return text(m["filename"])
+	}
+	return text(v)
+}
+
+func author(v any) string {
+	if m, ok := v.(map[string]any); ok {
+		return text(m["name"])
+	}
+	return text(v)
+}
+
+// Encode renders the feed in format
+func (f *Feed) Encode(format Format) ([]byte, error) {
+	switch format {
+	case RSS:
+		return f.rss()
+	case Atom:
+		return f.atom()
+	case JSONFeed:
+		return f.jsonFeed()
+	}
+	return nil, fmt.Errorf("feed: unknown format %q", format)
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Author      string        `xml:"author,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Content     string        `xml:"content:encoded,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int    `xml:"length,attr"`
+}
+
+func (f *Feed) rss() ([]byte, error) {
+	doc := rssDoc{Version: "2.0", Content: "http://purl.org/rss/1.0/modules/content/", Channel: rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		Language:    f.Language,
+	}}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, item := range f.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: item.ID == item.Link, Value: item.ID},
+			Author:      item.Author,
+			Description: item.Summary,
+			Content:     item.Content,
+		}
+		if !item.Published.IsZero() {
+			ri.PubDate = item.Published.UTC().Format(time.RFC1123Z)
+		}
+		if item.Image != "" {
+			ri.Enclosure = &rssEnclosure{URL: item.Image, Type: imageType(item.Image)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	return marshalXML(doc)
+}
+
+type atomDoc struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published,omitempty"`
+	Links     []atomLink  `xml:"link"`
+	Author    *atomAuthor `xml:"author"`
+	Summary   *atomText   `xml:"summary"`
+	Content   *atomText   `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+func (f *Feed) atom() ([]byte, error) {
+	doc := atomDoc{ID: f.Link, Title: f.Title, Updated: f.Updated.UTC().Format(time.RFC3339), Links: []atomLink{{Href: f.Link, Rel: "alternate"}}}
+	if f.FeedURL != "" {
+		doc.ID = f.FeedURL
+		doc.Links = append(doc.Links, atomLink{Href: f.FeedURL, Rel: "self"})
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Updated: item.Updated.UTC().Format(time.RFC3339),
+			Links:   []atomLink{{Href: item.Link, Rel: "alternate"}},
+		}
+		if !strings.Contains(entry.ID, ":") {
+			entry.ID = "urn:uuid:" + entry.ID
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		if item.Image != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.Image, Rel: "enclosure", Type: imageType(item.Image)})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
+
+type jsonFeedDoc struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished *time.Time       `json:"date_published,omitempty"`
+	DateModified  *time.Time       `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func (f *Feed) jsonFeed() ([]byte, error) {
+	doc := jsonFeedDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range f.Items {
+		ji := jsonFeedItem{ID: item.ID, URL: item.Link, Title: item.Title, ContentHTML: item.Content, Summary: item.Summary, Image: item.Image}
+		if !item.Published.IsZero() {
+			published := item.Published.UTC()
+			ji.DatePublished = &published
+		}
+		if !item.Updated.IsZero() {
+			updated := item.Updated.UTC()
+			ji.DateModified = &updated
+		}
+		if item.Author != "" {
+			ji.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, ji)
+	}
+	return json.Marshal(doc)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("feed: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func imageType(u string) string {
+	switch {
+	case strings.HasSuffix(strings.ToLower(u), ".png"):
+		return "image/png"
+	case strings.HasSuffix(strings.ToLower(u), ".gif"):
+		return "image/gif"
+	case strings.HasSuffix(strings.ToLower(u), ".webp"):
+		return "image/webp"
+	case strings.HasSuffix(strings.ToLower(u), ".svg"):
+		return "image/svg+xml"
+	}
+	return "image/jpeg"
+}
+
+// Handler serves the feed in format. The encoded feed is kept in the cache of the client with the tags
+// of its stories, so it is rebuilt together with the stories after EmptyCache or PurgeTags. Drafts are not cached.
+func Handler(client *storyblok.Client, opts Options, format Format) http.Handler {
+	opts.defaults()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		cache := client.Cache()
+		// per request, transformers added with Use change the key
+		cacheKey := client.CacheKey("f"+string(format), opts.StartsWith, opts.Version, opts.Language)
+		cached := client.CachesVersion(opts.Version)
+		var body []byte
+		if cached {
+			body, _ = cache.Get(ctx, cacheKey)
+		}
+		if body == nil {
+			f, err := Build(ctx, client, opts)
+			if err == nil {
+				body, err = f.Encode(format)
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "feed - build", slog.String("starts_with", opts.StartsWith), slog.Any("err", err))
+				http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+				return
+			}
+			if cached {
+				if err := headless_cms.SetTagged(ctx, cache, cacheKey, body, f.Tags...); err != nil {
+					slog.WarnContext(ctx, "feed - cache.Set", slog.String("key", cacheKey), slog.Any("err", err))
+				}
+			}
+		}
+		w.Header().Set("Content-Type", contentTypes[format])
+		w.Write(body)
+	})
+}
@@ -0,0 +1,115 @@
+package feed_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+	"github.com/dryaf/headless_cms/feed"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCDN struct {
+	requests atomic.Int64
+	query    atomic.Value
+}
+
+func (f *fakeCDN) Do(req *http.Request) (*http.Response, error) {
+	f.requests.Add(1)
+	f.query.Store(req.URL.Query())
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"stories": [
+		{"id": 1, "uuid": "u1", "name": "Old", "full_slug": "blog/old", "first_published_at": "2024-01-01T00:00:00Z", "published_at": "2024-02-01T00:00:00Z",
+			"content": {"summary": "old one", "author": {"name": "Kim"}}},
+		{"id": 2, "uuid": "u2", "name": "New", "full_slug": "blog/new", "tag_list": ["launch"], "first_published_at": "2024-03-01T00:00:00Z",
+			"content": {"title": "Brand <new>", "image": {"filename": "https://a.storyblok.com/f/1/new.png"},
+				"body": {"type": "doc", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "Hello"}]}]}}}
+	]}`))}, nil
+}
+
+func opts() feed.Options {
+	return feed.Options{StartsWith: "blog/", BaseURL: "https://example.com/", FeedURL: "https://example.com/blog/feed.xml", Title: "Blog"}
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	cdn := &fakeCDN{}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+
+	f, err := feed.Build(ctx, client, opts())
+	require.NoError(t, err)
+	assert.Equal(t, "blog/", cdn.query.Load().(url.Values).Get("starts_with"))
+	require.Len(t, f.Items, 2)
+	assert.Equal(t, "Brand <new>", f.Items[0].Title)
+	assert.Equal(t, "https://example.com/blog/new", f.Items[0].Link)
+	assert.Equal(t, "<p>Hello</p>", f.Items[0].Content)
+	assert.Equal(t, "https://a.storyblok.com/f/1/new.png", f.Items[0].Image)
+	assert.Equal(t, "Old", f.Items[1].Title)
+	assert.Equal(t, "Kim", f.Items[1].Author)
+	assert.Equal(t, "2024-03-01", f.Updated.Format("2006-01-02"))
+	assert.Equal(t, []string{"story:2", "tag:launch", "story:1"}, f.Tags)
+
+	rss, err := f.Encode(feed.RSS)
+	require.NoError(t, err)
+	require.NoError(t, xml.Unmarshal(rss, &struct{}{}))
+	assert.Contains(t, string(rss), `<title>Brand &lt;new&gt;</title>`)
+	assert.Contains(t, string(rss), `<content:encoded>&lt;p&gt;Hello&lt;/p&gt;</content:encoded>`)
+	assert.Contains(t, string(rss), `<enclosure url="https://a.storyblok.com/f/1/new.png" type="image/png" length="0"></enclosure>`)
+
+	atom, err := f.Encode(feed.Atom)
+	require.NoError(t, err)
+	assert.Contains(t, string(atom), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, string(atom), `<id>urn:uuid:u2</id>`)
+	assert.Contains(t, string(atom), `<link href="https://example.com/blog/feed.xml" rel="self"></link>`)
+
+	jsonFeed, err := f.Encode(feed.JSONFeed)
+	require.NoError(t, err)
+	doc := map[string]any{}
+	require.NoError(t, json.Unmarshal(jsonFeed, &doc))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc["version"])
+	assert.Equal(t, "2024-03-01T00:00:00Z", doc["items"].([]any)[0].(map[string]any)["date_published"])
+
+	_, err = f.Encode("yaml")
+	require.Error(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	cdn := &fakeCDN{}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	handler := feed.Handler(client, opts(), feed.Atom)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/blog/feed.xml", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/atom+xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	}
+	assert.Equal(t, int64(1), cdn.requests.Load())
+
+	require.NoError(t, client.EmptyCache(ctx, "empty_cache_token"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/blog/feed.xml", nil))
+	assert.Equal(t, int64(2), cdn.requests.Load())
+
+	// purging a listed story rebuilds the feed
+	require.NoError(t, client.PurgeTags(ctx, "empty_cache_token", storyblok.StoryTag(1)))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/blog/feed.xml", nil))
+	assert.Equal(t, int64(3), cdn.requests.Load())
+
+	// transformers added after the handler was built are part of the key
+	client.Use(storyblok.StripEditable())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/blog/feed.xml", nil))
+	assert.Equal(t, int64(4), cdn.requests.Load())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/blog/feed.xml", nil))
+	assert.Equal(t, int64(4), cdn.requests.Load())
+}
@@ -4,9 +4,13 @@ go 1.21
 
 require (
 	github.com/joho/godotenv v1.5.1
+	github.com/klauspost/compress v1.17.4
 	github.com/stretchr/testify v1.8.4
+	go.etcd.io/bbolt v1.3.8
 )
 
+require golang.org/x/sys v0.4.0 // indirect
+
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
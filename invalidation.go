@@ -0,0 +1,69 @@
+package headless_cms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type InvalidationKind string
+
+const (
+	InvalidateDel   InvalidationKind = "del"
+	InvalidateEmpty InvalidationKind = "empty"
+	InvalidateTags  InvalidationKind = "tags"
+)
+
+// Invalidation is broadcast to other instances so they can drop entries from their local caches.
+// Origin identifies the sender so it can skip its own messages.
+type Invalidation struct {
+	Kind   InvalidationKind `json:"kind"`
+	Keys   []string         `json:"keys,omitempty"`
+	Tags   []string         `json:"tags,omitempty"`
+	Origin string           `json:"origin,omitempty"`
+}
+
+// InvalidationBus delivers invalidations between instances sharing a backend.
+// Subscribe registers handler and keeps delivering messages until ctx is done.
+type InvalidationBus interface {
+	Publish(ctx context.Context, msg Invalidation) error
+	Subscribe(ctx context.Context, handler func(ctx context.Context, msg Invalidation)) error
+}
+
+// TagPurger is implemented by caches that can drop all entries carrying one of the tags.
+type TagPurger interface {
+	PurgeTags(ctx context.Context, tags ...string) error
+}
+
+// TagSetter is implemented by caches that record the tags of an entry for PurgeTags.
+type TagSetter interface {
+	SetWithTags(ctx context.Context, key string, bytes []byte, tags ...string) error
+}
+
+// SetTagged sets key with tags in caches that are a TagSetter and without them in the others.
+func SetTagged(ctx context.Context, cache Cache, key string, bytes []byte, tags ...string) error {
+	if ts, ok := cache.(TagSetter); ok {
+		return ts.SetWithTags(ctx, key, bytes, tags...)
+	}
+	return cache.Set(ctx, key, bytes)
+}
+
+// ApplyInvalidation applies msg to cache. Tag purges empty caches that are not a TagPurger.
+func ApplyInvalidation(ctx context.Context, cache Cache, msg Invalidation) error {
+	switch msg.Kind {
+	case InvalidateDel:
+		var err error
+		for _, key := range msg.Keys {
+			err = errors.Join(err, cache.Del(ctx, key))
+		}
+		return err
+	case InvalidateEmpty:
+		return cache.Empty(ctx)
+	case InvalidateTags:
+		if tp, ok := cache.(TagPurger); ok {
+			return tp.PurgeTags(ctx, msg.Tags...)
+		}
+		return cache.Empty(ctx)
+	}
+	return fmt.Errorf("headless_cms: unknown invalidation kind %q", msg.Kind)
+}
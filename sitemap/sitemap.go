@@ -0,0 +1,303 @@
+// Package sitemap generates sitemap.xml with hreflang alternates from the stories of a Storyblok space.
+//
+// Field level translations are listed for every language, under /<language>/<path> where path is the
+// translated slug when the Translatable Slugs app provides one. Folder level translations, stories in a
+// language folder or with Story.Alternates, are listed once and linked through Story.Alternates,
+// their language is the first folder of their full slug.
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+// MaxURLs is the limit of URLs per sitemap file of the sitemaps protocol
+const MaxURLs = 50000
+
+type Options struct {
+	BaseURL         string   // "https://example.com"
+	Version         string   // "published"
+	Languages       []string // nil: all language codes of the space, the default language is always included
+	DefaultLanguage string   // hreflang of the default language, e.g. "en", x-default is added to all alternates
+	HomeSlug        string   // "home", served at BaseURL/
+	MaxURLs         int      // MaxURLs, more URLs are split into sitemap-<n>.xml files and an index
+
+	// Exclude skips stories, e.g. noindex pages
+	Exclude func(story storyblok.Story) bool
+}
+
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	Alternates []Alternate
+}
+
+type Alternate struct {
+	Hreflang string
+	Href     string
+}
+
+func (o *Options) defaults() {
+	if o.Version == "" {
+		o.Version = "published"
+	}
+	if o.HomeSlug == "" {
+		o.HomeSlug = "home"
+	}
+	if o.MaxURLs <= 0 || o.MaxURLs > MaxURLs {
+		o.MaxURLs = MaxURLs
+	}
+	o.BaseURL = strings.TrimSuffix(o.BaseURL, "/")
+}
+
+// hash identifies the options in the cache keys of Handler, so handlers with other options don't serve
+// each other's files. Exclude can't be compared, handlers differing only in Exclude share their files.
+func (o Options) hash() string {
+	data, _ := json.Marshal([]any{o.BaseURL, o.Version, o.Languages, o.DefaultLanguage, o.HomeSlug, o.MaxURLs})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// URLs lists every story in every language with its alternates
+func URLs(ctx context.Context, client *storyblok.Client, opts Options) ([]URL, error) {
+	opts.defaults()
+	if opts.Languages == nil {
+		space, err := client.Space(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap: %w", err)
+		}
+		opts.Languages = space.LanguageCodes
+	}
+	isLanguage := map[string]bool{}
+	for _, language := range opts.Languages {
+		isLanguage[language] = true
+	}
+
+	raw, err := client.Stories(ctx, opts.Version, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: %w", err)
+	}
+	urls := []URL{}
+	for _, r := range raw {
+		story := storyblok.Story{}
+		if err := json.Unmarshal(r, &story); err != nil {
+			return nil, fmt.Errorf("sitemap: story: %w", err)
+		}
+		if opts.Exclude != nil && opts.Exclude(story) {
+			continue
+		}
+
+		defaultHref := opts.href("", story.FullSlug)
+		locs := []string{defaultHref}
+		alternates := []Alternate{}
+		folder, _, _ := strings.Cut(story.FullSlug, "/")
+		folderAlternates := alternateSlugs(story.Alternates)
+		if isLanguage[folder] || len(folderAlternates) > 0 {
+			// folder level translations are stories of their own, they are only linked
+			xDefault := ""
+			for _, fullSlug := range append([]string{story.FullSlug}, folderAlternates...) {
+				href := opts.href("", fullSlug)
+				language, _, _ := strings.Cut(fullSlug, "/")
+				if !isLanguage[language] {
+					language = opts.DefaultLanguage
+					xDefault = href
+				}
+				if language != "" {
+					alternates = append(alternates, Alternate{Hreflang: language, Href: href})
+				}
+			}
+			if len(alternates) > 0 && xDefault != "" {
+				alternates = append(alternates, Alternate{Hreflang: "x-default", Href: xDefault})
+			}
+		} else {
+			// field level translations, the story in every language of the space, listed as URLs of their own
+			if opts.DefaultLanguage != "" {
+				alternates = append(alternates, Alternate{Hreflang: opts.DefaultLanguage, Href: defaultHref})
+			}
+			translated := translatedPaths(story.TranslatedSlugs)
+			for _, language := range opts.Languages {
+				p, ok := translated[language]
+				if !ok {
+					p = story.FullSlug
+				}
+				href := opts.href(language, p)
+				locs = append(locs, href)
+				alternates = append(alternates, Alternate{Hreflang: language, Href: href})
+			}
+			if len(alternates) > 0 {
+				alternates = append(alternates, Alternate{Hreflang: "x-default", Href: defaultHref})
+			}
+		}
+		for _, loc := range locs {
+			urls = append(urls, URL{Loc: loc, LastMod: story.PublishedAt, Alternates: alternates})
+		}
+	}
+	return urls, nil
+}
+
+func (o *Options) href(language, fullSlug string) string {
+	p := strings.Trim(fullSlug, "/")
+	if p == o.HomeSlug {
+		p = ""
+	}
+	p = path.Join("/", language, p)
+	if p != "/" && strings.HasSuffix(fullSlug, "/") {
+		p += "/"
+	}
+	return o.BaseURL + p
+}
+
+// translatedPaths reads translated_slugs, [{"path": "ueber-uns", "lang": "de", "published": true}]
+func translatedPaths(v any) map[string]string {
+	paths := map[string]string{}
+	list, _ := v.([]any)
+	for _, item := range list {
+		m, _ := item.(map[string]any)
+		p, _ := m["path"].(string)
+		lang, _ := m["lang"].(string)
+		if published, ok := m["published"].(bool); ok && !published {
+			continue
+		}
+		if p != "" && lang != "" {
+			paths[lang] = p
+		}
+	}
+	return paths
+}
+
+// alternateSlugs reads alternates, [{"full_slug": "de/ueber-uns", "published": true}]
+func alternateSlugs(v []any) []string {
+	slugs := []string{}
+	for _, item := range v {
+		m, _ := item.(map[string]any)
+		fullSlug, _ := m["full_slug"].(string)
+		if published, ok := m["published"].(bool); ok && !published {
+			continue
+		}
+		if fullSlug != "" {
+			slugs = append(slugs, fullSlug)
+		}
+	}
+	return slugs
+}
+
+// Generate returns the sitemap files by name. With up to MaxURLs URLs that is only sitemap.xml,
+// otherwise sitemap.xml is an index of sitemap-1.xml, sitemap-2.xml, ...
+func Generate(ctx context.Context, client *storyblok.Client, opts Options) (map[string][]byte, error) {
+	opts.defaults()
+	urls, err := URLs(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	if len(urls) <= opts.MaxURLs {
+		files["sitemap.xml"] = urlset(urls)
+		return files, nil
+	}
+	names := []string{}
+	for i := 0; i*opts.MaxURLs < len(urls); i++ {
+		name := fmt.Sprintf("sitemap-%d.xml", i+1)
+		files[name] = urlset(urls[i*opts.MaxURLs : min((i+1)*opts.MaxURLs, len(urls))])
+		names = append(names, name)
+	}
+	files["sitemap.xml"] = index(opts.BaseURL, names)
+	return files, nil
+}
+
+func urlset(urls []URL) []byte {
+	b := &bytes.Buffer{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">` + "\n")
+	for _, u := range urls {
+		b.WriteString("<url><loc>")
+		xml.EscapeText(b, []byte(u.Loc))
+		b.WriteString("</loc>")
+		if !u.LastMod.IsZero() {
+			b.WriteString("<lastmod>" + u.LastMod.UTC().Format(time.RFC3339) + "</lastmod>")
+		}
+		for _, a := range u.Alternates {
+			b.WriteString(`<xhtml:link rel="alternate" hreflang="`)
+			xml.EscapeText(b, []byte(a.Hreflang))
+			b.WriteString(`" href="`)
+			xml.EscapeText(b, []byte(a.Href))
+			b.WriteString(`"/>`)
+		}
+		b.WriteString("</url>\n")
+	}
+	b.WriteString("</urlset>\n")
+	return b.Bytes()
+}
+
+func index(baseURL string, names []string) []byte {
+	b := &bytes.Buffer{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, name := range names {
+		b.WriteString("<sitemap><loc>")
+		xml.EscapeText(b, []byte(baseURL+"/"+name))
+		b.WriteString("</loc></sitemap>\n")
+	}
+	b.WriteString("</sitemapindex>\n")
+	return b.Bytes()
+}
+
+// Handler serves sitemap.xml and sitemap-<n>.xml by the last path element of the request.
+// The files are kept in the cache of the client, so they are regenerated after EmptyCache.
+func Handler(client *storyblok.Client, opts Options) http.Handler {
+	opts.defaults()
+	hash := opts.hash()
+	cacheKey := func(name string) string {
+		return client.CacheKey("s", hash+"/"+name, opts.Version, "")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name := path.Base(r.URL.Path)
+		if name != "sitemap.xml" && !(strings.HasPrefix(name, "sitemap-") && strings.HasSuffix(name, ".xml")) {
+			http.NotFound(w, r)
+			return
+		}
+
+		cache := client.Cache()
+		body, err := cache.Get(ctx, cacheKey(name))
+		if (err != nil || body == nil) && name != "sitemap.xml" {
+			// unknown parts only regenerate when sitemap.xml is outdated too
+			if index, err := cache.Get(ctx, cacheKey("sitemap.xml")); err == nil && index != nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		if err != nil || body == nil {
+			files, err := Generate(ctx, client, opts)
+			if err != nil {
+				slog.ErrorContext(ctx, "sitemap - generate", slog.Any("err", err))
+				http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+				return
+			}
+			for n, data := range files {
+				if err := cache.Set(ctx, cacheKey(n), data); err != nil {
+					slog.WarnContext(ctx, "sitemap - cache.Set", slog.String("name", n), slog.Any("err", err))
+				}
+			}
+			body = files[name]
+		}
+		if body == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(body)
+	})
+}
@@ -0,0 +1,123 @@
+package sitemap_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+	"github.com/dryaf/headless_cms/sitemap"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCDN struct {
+	stories  string
+	requests atomic.Int64
+}
+
+func (f *fakeCDN) Do(req *http.Request) (*http.Response, error) {
+	f.requests.Add(1)
+	body := f.stories
+	if strings.HasSuffix(req.URL.Path, "/spaces/me") {
+		body = `{"space": {"language_codes": ["de"]}}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+const stories = `{"stories": [
+	{"full_slug": "home", "published_at": "2024-01-02T03:04:05Z"},
+	{"full_slug": "about", "translated_slugs": [{"path": "ueber-uns", "lang": "de", "published": true}]},
+	{"full_slug": "contact", "alternates": [{"full_slug": "de/kontakt", "published": true}, {"full_slug": "es/contacto", "published": false}]},
+	{"full_slug": "de/kontakt", "alternates": [{"full_slug": "contact", "published": true}]}
+]}`
+
+func TestURLs(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), &fakeCDN{stories: stories})
+
+	urls, err := sitemap.URLs(ctx, client, sitemap.Options{BaseURL: "https://example.com/", DefaultLanguage: "en"})
+	require.NoError(t, err)
+	require.Len(t, urls, 6)
+
+	assert.Equal(t, "https://example.com/", urls[0].Loc)
+	assert.Equal(t, "2024-01-02T03:04:05Z", urls[0].LastMod.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "https://example.com/de", urls[1].Loc)
+
+	assert.Equal(t, "https://example.com/de/ueber-uns", urls[3].Loc)
+	assert.Equal(t, []sitemap.Alternate{
+		{Hreflang: "en", Href: "https://example.com/about"},
+		{Hreflang: "de", Href: "https://example.com/de/ueber-uns"},
+		{Hreflang: "x-default", Href: "https://example.com/about"},
+	}, urls[2].Alternates)
+
+	// folder level translations are listed once
+	assert.Equal(t, "https://example.com/contact", urls[4].Loc)
+	assert.Equal(t, []sitemap.Alternate{
+		{Hreflang: "en", Href: "https://example.com/contact"},
+		{Hreflang: "de", Href: "https://example.com/de/kontakt"},
+		{Hreflang: "x-default", Href: "https://example.com/contact"},
+	}, urls[4].Alternates)
+	assert.Equal(t, "https://example.com/de/kontakt", urls[5].Loc)
+	assert.Equal(t, []sitemap.Alternate{
+		{Hreflang: "de", Href: "https://example.com/de/kontakt"},
+		{Hreflang: "en", Href: "https://example.com/contact"},
+		{Hreflang: "x-default", Href: "https://example.com/contact"},
+	}, urls[5].Alternates)
+}
+
+func TestGenerateIndex(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), &fakeCDN{stories: stories})
+
+	files, err := sitemap.Generate(ctx, client, sitemap.Options{BaseURL: "https://example.com", Languages: []string{}, MaxURLs: 2})
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.Contains(t, string(files["sitemap.xml"]), "<sitemapindex")
+	assert.Contains(t, string(files["sitemap.xml"]), "<loc>https://example.com/sitemap-2.xml</loc>")
+	assert.Contains(t, string(files["sitemap-1.xml"]), "<url><loc>https://example.com/</loc><lastmod>2024-01-02T03:04:05Z</lastmod></url>")
+	assert.NotContains(t, string(files["sitemap-1.xml"]), "xhtml:link")
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	cdn := &fakeCDN{stories: stories}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	handler := sitemap.Handler(client, sitemap.Options{BaseURL: "https://example.com", Languages: []string{"de"}})
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	rec := get("/sitemap.xml")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<xhtml:link rel="alternate" hreflang="de" href="https://example.com/de/ueber-uns"/>`)
+
+	get("/sitemap.xml")
+	assert.Equal(t, int64(1), cdn.requests.Load())
+
+	require.NoError(t, client.EmptyCache(ctx, "empty_cache_token"))
+	get("/sitemap.xml")
+	assert.Equal(t, int64(2), cdn.requests.Load())
+
+	assert.Equal(t, http.StatusNotFound, get("/sitemap-9.xml").Code)
+	assert.Equal(t, int64(2), cdn.requests.Load())
+	assert.Equal(t, http.StatusNotFound, get("/robots.txt").Code)
+
+	// other options share the cache but not the files
+	other := sitemap.Handler(client, sitemap.Options{BaseURL: "https://example.org", Languages: []string{"de"}})
+	rec = httptest.NewRecorder()
+	other.ServeHTTP(rec, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	assert.Contains(t, rec.Body.String(), `href="https://example.org/de/ueber-uns"`)
+	assert.NotContains(t, rec.Body.String(), "https://example.com")
+	assert.Equal(t, int64(3), cdn.requests.Load())
+}
@@ -0,0 +1,354 @@
+// Package snapshot exports a Storyblok space into a directory or tarball and imports it into a cache.
+//
+// Layout of a snapshot:
+//
+//	manifest.json                        format version, space, version and languages
+//	stories/<language>/<full_slug>.json  {"story": {...}} like the CDN, readable by client/localfs
+//	links.json, tags.json, datasources.json
+//
+// The default language is stored as localfs.DefaultLanguage.
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms"
+	"github.com/dryaf/headless_cms/client/localfs"
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+// FormatVersion is increased on incompatible layout changes
+const FormatVersion = 1
+
+const storiesDir = localfs.SnapshotStoriesDir
+
+type Manifest struct {
+	Format    int             `json:"format"`
+	CreatedAt time.Time       `json:"created_at"`
+	Version   string          `json:"version"`
+	Space     storyblok.Space `json:"space"`
+	Languages []string        `json:"languages"`
+	Stories   int             `json:"stories"`
+}
+
+// IsTarball reports whether out is written as gzipped tarball instead of a directory
+func IsTarball(out string) bool {
+	return strings.HasSuffix(out, ".tar.gz") || strings.HasSuffix(out, ".tgz")
+}
+
+// Export writes every story in every language plus links, tags and datasources of version to out.
+func Export(ctx context.Context, client *storyblok.Client, version string, out string) (Manifest, error) {
+	manifest := Manifest{Format: FormatVersion, CreatedAt: time.Now().UTC(), Version: version}
+	w, err := newWriter(out)
+	if err != nil {
+		return manifest, err
+	}
+	err = export(ctx, client, w, &manifest)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return manifest, err
+}
+
+func export(ctx context.Context, client *storyblok.Client, w writer, manifest *Manifest) error {
+	space, err := client.Space(ctx)
+	if err != nil {
+		return err
+	}
+	manifest.Space = space
+	manifest.Languages = append([]string{""}, space.LanguageCodes...)
+
+	for _, language := range manifest.Languages {
+		stories, err := client.Stories(ctx, manifest.Version, language, nil)
+		if err != nil {
+			return fmt.Errorf("snapshot: stories %q: %w", language, err)
+		}
+		for _, story := range stories {
+			name, err := storyFileName(language, story)
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(w, name, map[string]json.RawMessage{"story": story}); err != nil {
+				return err
+			}
+		}
+		manifest.Stories += len(stories)
+		slog.InfoContext(ctx, "snapshot - exported stories", slog.String("language", language), slog.Int("count", len(stories)))
+	}
+
+	links, err := client.Links(ctx, manifest.Version)
+	if err != nil {
+		return fmt.Errorf("snapshot: links: %w", err)
+	}
+	if err := writeJSON(w, "links.json", map[string]any{"links": links}); err != nil {
+		return err
+	}
+
+	tags, err := client.Tags(ctx, manifest.Version)
+	if err != nil {
+		return fmt.Errorf("snapshot: tags: %w", err)
+	}
+	if err := writeJSON(w, "tags.json", map[string]any{"tags": tags}); err != nil {
+		return err
+	}
+
+	datasources, err := client.Datasources(ctx)
+	if err != nil {
+		return fmt.Errorf("snapshot: datasources: %w", err)
+	}
+	// entries by datasource slug and dimension, "" holds the default values
+	entries := map[string]map[string][]storyblok.DatasourceEntry{}
+	for _, ds := range datasources {
+		entries[ds.Slug] = map[string][]storyblok.DatasourceEntry{}
+		dimensions := []string{""}
+		for _, d := range ds.Dimensions {
+			dimensions = append(dimensions, d.EntryValue)
+		}
+		for _, dimension := range dimensions {
+			e, err := client.DatasourceEntries(ctx, ds.Slug, dimension)
+			if err != nil {
+				return fmt.Errorf("snapshot: datasource %q: %w", ds.Slug, err)
+			}
+			entries[ds.Slug][dimension] = e
+		}
+	}
+	if err := writeJSON(w, "datasources.json", map[string]any{"datasources": datasources, "entries": entries}); err != nil {
+		return err
+	}
+
+	// written last, so a snapshot without manifest is known to be incomplete
+	return writeJSON(w, "manifest.json", manifest)
+}
+
+// storyFileName places a story where client/localfs looks for it: the page argument of GetPage is the
+// untranslated full slug, so translated stories are stored under default_full_slug.
+func storyFileName(language string, story json.RawMessage) (string, error) {
+	s := struct {
+		FullSlug        string `json:"full_slug"`
+		DefaultFullSlug string `json:"default_full_slug"`
+	}{}
+	if err := json.Unmarshal(story, &s); err != nil {
+		return "", fmt.Errorf("snapshot: story: %w", err)
+	}
+	slug := s.FullSlug
+	if language != "" {
+		slug = strings.TrimPrefix(slug, language+"/")
+		if s.DefaultFullSlug != "" {
+			slug = s.DefaultFullSlug
+		}
+	} else {
+		language = localfs.DefaultLanguage
+	}
+	slug = strings.Trim(slug, "/")
+	if slug == "" {
+		return "", errors.New("snapshot: story without full_slug")
+	}
+	return path.Join(storiesDir, language, slug) + ".json", nil
+}
+
+// Import loads the stories of the snapshot at in into cache, under the same keys storyblok.Client
+// uses for GetPageAsJSON, GetPage and GetPageAsSimpleBlocksWithID, as well as the links of ResolveLinks
+// and the datasource values of DatasourceValue. Pass the transformers registered with Client.Use of the
+// serving client, the stories are transformed and stored under the keys of that pipeline.
+// It returns the number of stories. Snapshots of versions that are not cached, like draft, are rejected.
+func Import(ctx context.Context, in string, cache headless_cms.Cache, transformers ...storyblok.Transformer) (int, error) {
+	dir := in
+	if IsTarball(in) {
+		tmp, err := os.MkdirTemp("", "headless_cms_snapshot")
+		if err != nil {
+			return 0, err
+		}
+		defer os.RemoveAll(tmp)
+		if err := extract(in, tmp); err != nil {
+			return 0, err
+		}
+		dir = tmp
+	}
+
+	manifest := Manifest{}
+	manifestJSON, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return 0, fmt.Errorf("snapshot: incomplete: %w", err)
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return 0, fmt.Errorf("snapshot: manifest: %w", err)
+	}
+	if manifest.Format != FormatVersion {
+		return 0, fmt.Errorf("snapshot: format %d not supported, expected %d", manifest.Format, FormatVersion)
+	}
+
+	// the storyblok client fills the cache exactly as it would in production
+	files := &localfs.Files{FS: os.DirFS(dir), DefaultLanguage: localfs.DefaultLanguage, StoriesDir: storiesDir}
+	client := storyblok.NewClient(ctx, "snapshot", "snapshot", cache, files)
+	client.Use(transformers...)
+	if !client.CachesVersion(manifest.Version) {
+		return 0, fmt.Errorf("snapshot: version %q is not cached, export the published version", manifest.Version)
+	}
+
+	if err := client.RefreshLinks(ctx); err != nil {
+		return 0, fmt.Errorf("snapshot: import links: %w", err)
+	}
+	datasources, err := client.Datasources(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("snapshot: import datasources: %w", err)
+	}
+	for _, ds := range datasources {
+		dimensions := []string{""}
+		for _, d := range ds.Dimensions {
+			dimensions = append(dimensions, d.EntryValue)
+		}
+		for _, dimension := range dimensions {
+			if err := client.RefreshDatasource(ctx, ds.Slug, dimension); err != nil {
+				return 0, fmt.Errorf("snapshot: import datasource %q: %w", ds.Slug, err)
+			}
+		}
+	}
+
+	count := 0
+	for _, language := range manifest.Languages {
+		languageDir := language
+		if language == "" {
+			languageDir = localfs.DefaultLanguage
+		}
+		root := filepath.Join(dir, storiesDir, languageDir)
+		err := filepath.WalkDir(root, func(name string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(name) != ".json" {
+				return err
+			}
+			rel, err := filepath.Rel(root, name)
+			if err != nil {
+				return err
+			}
+			page := strings.TrimSuffix(filepath.ToSlash(rel), ".json")
+			// replace what the cache holds instead of reading it back, "ip" holds the blocks keyed by path
+			for _, prefix := range []string{"j", "r", "i", "ip"} {
+				if err := cache.Del(ctx, client.CacheKey(prefix, page, manifest.Version, language)); err != nil {
+					return err
+				}
+			}
+			if _, err := client.GetPage(ctx, page, manifest.Version, language); err != nil {
+				return err
+			}
+			if _, err := client.GetPageAsSimpleBlocksWithID(ctx, page, manifest.Version, language); err != nil {
+				return err
+			}
+			count++
+			return nil
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return count, fmt.Errorf("snapshot: import %q: %w", language, err)
+		}
+	}
+	return count, nil
+}
+
+func writeJSON(w writer, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("snapshot: %s: %w", name, err)
+	}
+	return w.WriteFile(name, data)
+}
+
+type writer interface {
+	WriteFile(name string, data []byte) error
+	Close() error
+}
+
+func newWriter(out string) (writer, error) {
+	if !IsTarball(out) {
+		if err := os.MkdirAll(out, 0o755); err != nil {
+			return nil, err
+		}
+		return dirWriter(out), nil
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	return &tarWriter{f: f, gz: gz, tw: tar.NewWriter(gz)}, nil
+}
+
+type dirWriter string
+
+func (d dirWriter) WriteFile(name string, data []byte) error {
+	p := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+func (d dirWriter) Close() error {
+	return nil
+}
+
+type tarWriter struct {
+	f  *os.File
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (t *tarWriter) WriteFile(name string, data []byte) error {
+	err := t.tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Now(), Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+	_, err = t.tw.Write(data)
+	return err
+}
+
+func (t *tarWriter) Close() error {
+	return errors.Join(t.tw.Close(), t.gz.Close(), t.f.Close())
+}
+
+// extract unpacks the regular files of a gzipped tarball into dir
+func extract(in string, dir string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(h.Name) {
+			return fmt.Errorf("snapshot: invalid path %q", h.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := dirWriter(dir).WriteFile(h.Name, data); err != nil {
+			return err
+		}
+	}
+}
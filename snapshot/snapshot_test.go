@@ -0,0 +1,124 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+	"github.com/dryaf/headless_cms/snapshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCDN answers the CDN endpoints used by Export
+type fakeCDN map[string]string
+
+func (f fakeCDN) Do(req *http.Request) (*http.Response, error) {
+	key := strings.TrimPrefix(req.URL.Path, "/v2/cdn/")
+	if language := req.URL.Query().Get("language"); language != "" {
+		key += "?" + language
+	}
+	body, ok := f[key]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+var cdn = fakeCDN{
+	"spaces/me":          `{"space": {"id": 7, "name": "Site", "language_codes": ["de"]}}`,
+	"stories":            `{"stories": [{"full_slug": "home", "content": {"body": [{"id": "headline", "text": "Hello"}]}}, {"full_slug": "blog/first-post"}]}`,
+	"stories?de":         `{"stories": [{"full_slug": "de/startseite", "default_full_slug": "home", "content": {"body": [{"id": "headline", "text": "Hallo"}]}}]}`,
+	"links":              `{"links": {"u1": {"uuid": "u1", "slug": "home"}}}`,
+	"tags":               `{"tags": [{"name": "news", "taggings_count": 1}]}`,
+	"datasources":        `{"datasources": [{"slug": "colors"}]}`,
+	"datasource_entries": `{"datasource_entries": [{"name": "red", "value": "#f00"}]}`,
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+
+	for _, out := range []string{filepath.Join(t.TempDir(), "snap"), filepath.Join(t.TempDir(), "snap.tar.gz")} {
+		manifest, err := snapshot.Export(ctx, client, "published", out)
+		require.NoError(t, err)
+		assert.Equal(t, 3, manifest.Stories)
+		assert.Equal(t, []string{"", "de"}, manifest.Languages)
+		assert.Equal(t, 7, manifest.Space.ID)
+
+		cache := memory_cache.New()
+		count, err := snapshot.Import(ctx, out, cache)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		importer := storyblok.NewClient(ctx, "unused", "empty_cache_token", cache, fakeCDN{})
+		blocks, err := importer.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "de")
+		require.NoError(t, err)
+		assert.Equal(t, "Hallo", blocks["headline"]["text"])
+
+		page, err := importer.GetPage(ctx, "blog/first-post", "published", "")
+		require.NoError(t, err)
+		assert.Equal(t, "blog/first-post", page["story"].(map[string]any)["full_slug"])
+
+		// links of ResolveLinks and datasource values are imported as well
+		links, err := cache.Get(ctx, importer.CacheKey("l", "", "published", ""))
+		require.NoError(t, err)
+		assert.Contains(t, string(links), `"slug":"home"`)
+		value, err := importer.DatasourceValue(ctx, "colors", "red", "")
+		require.NoError(t, err)
+		assert.Equal(t, "#f00", value)
+	}
+}
+
+func TestImportDraft(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	out := filepath.Join(t.TempDir(), "snap")
+	_, err := snapshot.Export(ctx, client, "draft", out)
+	require.NoError(t, err)
+
+	count, err := snapshot.Import(ctx, out, memory_cache.New())
+	require.ErrorContains(t, err, `version "draft" is not cached`)
+	assert.Zero(t, count)
+}
+
+func TestImportTransformed(t *testing.T) {
+	ctx := context.Background()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	out := filepath.Join(t.TempDir(), "snap")
+	_, err := snapshot.Export(ctx, client, "published", out)
+	require.NoError(t, err)
+
+	upper := storyblok.ContentTransformer("upper", "1", func(n storyblok.Node) (map[string]any, error) {
+		if text, ok := n.Value["text"].(string); ok {
+			n.Value["upper"] = strings.ToUpper(text)
+		}
+		return n.Value, nil
+	})
+	cache := memory_cache.New()
+	_, err = snapshot.Import(ctx, out, cache, upper)
+	require.NoError(t, err)
+
+	// the serving client with the same pipeline reads the imported stories without the CDN
+	importer := storyblok.NewClient(ctx, "unused", "empty_cache_token", cache, fakeCDN{})
+	importer.Use(upper)
+	blocks, err := importer.GetPageAsSimpleBlocksWithID(ctx, "home", "published", "")
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", blocks["headline"]["upper"])
+}
+
+func TestImportIncomplete(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "stories"), 0o755))
+	_, err := snapshot.Import(context.Background(), dir, memory_cache.New())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "incomplete")
+}
@@ -0,0 +1,380 @@
+// Package static_site renders every story of a Storyblok space into static HTML files.
+//
+// Each story is fetched with GetPage, so caching applies, rendered by a RenderFunc (e.g. a html/template set)
+// and written to <out>/<full_slug>/index.html. Translated stories have full slugs prefixed with their language.
+// Assets referenced in the output are downloaded to <out>/assets/ and the references rewritten.
+// The published_at of every written story is kept in <out>/.static_site.json, unchanged stories are skipped on the next run.
+package static_site
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"log/slog"
+
+	"github.com/dryaf/headless_cms/client/storyblok"
+)
+
+const stateFile = ".static_site.json"
+
+// Page is the data passed to the RenderFunc
+type Page struct {
+	Data     map[string]any  // GetPage response, {"story": {...}}
+	Story    map[string]any  // Data["story"]
+	FullSlug string          // language prefixed full slug, without leading or trailing slash
+	Slug     string          // page argument of GetPage, the untranslated full slug
+	Language string          // "" for the default language
+	Ctx      context.Context // context of the export, for the funcs of storyblok.Client.FuncMap
+}
+
+type RenderFunc func(ctx context.Context, w io.Writer, page Page) error
+
+// Template renders pages with the template name of t, or with the template named like the
+// component of the story content (e.g. "page", "article") when name is "".
+func Template(t *template.Template, name string) RenderFunc {
+	return func(ctx context.Context, w io.Writer, page Page) error {
+		if name != "" {
+			return t.ExecuteTemplate(w, name, page)
+		}
+		content, _ := page.Story["content"].(map[string]any)
+		component, _ := content["component"].(string)
+		if component == "" {
+			return fmt.Errorf("static_site: %s: story without content component", page.FullSlug)
+		}
+		return t.ExecuteTemplate(w, component, page)
+	}
+}
+
+type Exporter struct {
+	Version     string   // "published"
+	Languages   []string // nil: the default language and all language codes of the space
+	Concurrency int      // runtime.NumCPU()
+	HomeSlug    string   // "home", additionally written to <out>/index.html and <out>/<language>/index.html
+	Force       bool     // render unchanged stories too
+
+	AssetHosts  []string             // ["a.storyblok.com"], references to other hosts are kept
+	AssetPrefix string               // "/assets/", URL path the assets directory is served under
+	HttpClient  storyblok.HTTPClient // used for assets, http.DefaultClient
+
+	client *storyblok.Client
+	render RenderFunc
+	out    string
+
+	assetsMu sync.Mutex
+	assets   map[string]*assetDownload
+}
+
+type Stats struct {
+	Rendered int
+	Skipped  int
+	Removed  int
+	Assets   int
+	Failed   int
+}
+
+type assetDownload struct {
+	done chan struct{}
+	err  error
+}
+
+func New(client *storyblok.Client, out string, render RenderFunc) *Exporter {
+	return &Exporter{
+		Version:     "published",
+		Concurrency: runtime.NumCPU(),
+		HomeSlug:    "home",
+		AssetHosts:  []string{"a.storyblok.com"},
+		AssetPrefix: "/assets/",
+		HttpClient:  http.DefaultClient,
+
+		client: client,
+		render: render,
+		out:    out,
+	}
+}
+
+type story struct {
+	FullSlug        string `json:"full_slug"`
+	DefaultFullSlug string `json:"default_full_slug"`
+	PublishedAt     string `json:"published_at"`
+}
+
+type job struct {
+	key      string
+	page     Page
+	modified string
+}
+
+// Export renders all stories. Failing stories do not stop the export, they are retried on the next run
+// and their errors are joined into the returned error.
+func (e *Exporter) Export(ctx context.Context) (Stats, error) {
+	stats := Stats{}
+	e.assets = map[string]*assetDownload{}
+	languages := e.Languages
+	if languages == nil {
+		space, err := e.client.Space(ctx)
+		if err != nil {
+			return stats, fmt.Errorf("static_site: %w", err)
+		}
+		languages = append([]string{""}, space.LanguageCodes...)
+	}
+
+	previous := map[string]string{}
+	if data, err := os.ReadFile(filepath.Join(e.out, stateFile)); err == nil {
+		if err := json.Unmarshal(data, &previous); err != nil {
+			slog.WarnContext(ctx, "static_site - state unreadable, rendering all", slog.Any("err", err))
+		}
+	}
+
+	jobs := []job{}
+	listed := map[string]bool{}
+	for _, language := range languages {
+		stories, err := e.client.Stories(ctx, e.Version, language, nil)
+		if err != nil {
+			return stats, fmt.Errorf("static_site: stories %q: %w", language, err)
+		}
+		for _, raw := range stories {
+			s := story{}
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return stats, fmt.Errorf("static_site: story: %w", err)
+			}
+			page := Page{Language: language, FullSlug: strings.Trim(s.FullSlug, "/")}
+			page.Slug = page.FullSlug
+			if language != "" {
+				page.Slug = strings.TrimPrefix(page.FullSlug, language+"/")
+				if s.DefaultFullSlug != "" {
+					page.Slug = strings.Trim(s.DefaultFullSlug, "/")
+				}
+				if !strings.HasPrefix(page.FullSlug, language+"/") {
+					page.FullSlug = language + "/" + page.FullSlug
+				}
+			}
+			if page.Slug == "" || !filepath.IsLocal(filepath.FromSlash(page.FullSlug)) {
+				return stats, fmt.Errorf("static_site: invalid full_slug %q", s.FullSlug)
+			}
+			jobs = append(jobs, job{key: page.FullSlug, page: page, modified: s.PublishedAt})
+			listed[page.FullSlug] = true
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		errs   []error
+		wg     sync.WaitGroup
+		state  = map[string]string{}
+		tokens = make(chan struct{}, max(e.Concurrency, 1))
+	)
+	for _, j := range jobs {
+		if !e.Force && j.modified != "" && previous[j.key] == j.modified {
+			if _, err := os.Stat(e.file(j.page.FullSlug)); err == nil {
+				// the workers of earlier jobs write state and stats concurrently
+				mu.Lock()
+				state[j.key] = j.modified
+				stats.Skipped++
+				mu.Unlock()
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		tokens <- struct{}{}
+		wg.Add(1)
+		go func(j job) {
+			defer func() { <-tokens; wg.Done() }()
+			assets, err := e.renderPage(ctx, j.page)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				slog.WarnContext(ctx, "static_site - render", slog.String("full_slug", j.key), slog.Any("err", err))
+				errs = append(errs, err)
+				stats.Failed++
+				return
+			}
+			state[j.key] = j.modified
+			stats.Rendered++
+			stats.Assets += assets
+		}(j)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	// stories deleted or unpublished since the previous run
+	for key := range previous {
+		if listed[key] || !filepath.IsLocal(filepath.FromSlash(key)) {
+			continue
+		}
+		if err := os.Remove(e.file(key)); err == nil {
+			stats.Removed++
+		}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return stats, err
+	}
+	if err := writeFile(filepath.Join(e.out, stateFile), data); err != nil {
+		errs = append(errs, err)
+	}
+	return stats, errors.Join(errs...)
+}
+
+func (e *Exporter) file(fullSlug string) string {
+	return filepath.Join(e.out, filepath.FromSlash(fullSlug), "index.html")
+}
+
+// renderPage writes the page and returns the number of downloaded assets
+func (e *Exporter) renderPage(ctx context.Context, page Page) (int, error) {
+	data, err := e.client.GetPage(ctx, page.Slug, e.Version, page.Language)
+	if err != nil {
+		return 0, err
+	}
+	page.Data = data
+	page.Story, _ = data["story"].(map[string]any)
+	page.Ctx = ctx
+
+	buf := &bytes.Buffer{}
+	if err := e.render(ctx, buf, page); err != nil {
+		return 0, fmt.Errorf("static_site: %s: render: %w", page.FullSlug, err)
+	}
+	html, assets, err := e.copyAssets(ctx, buf.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("static_site: %s: %w", page.FullSlug, err)
+	}
+
+	if err := writeFile(e.file(page.FullSlug), html); err != nil {
+		return 0, err
+	}
+	if page.Slug == e.HomeSlug {
+		if err := writeFile(filepath.Join(e.out, page.Language, "index.html"), html); err != nil {
+			return 0, err
+		}
+	}
+	return assets, nil
+}
+
+// copyAssets downloads the assets referenced in html and rewrites them to AssetPrefix.
+// Query strings stay untouched, so image service parameters have to be part of the path.
+func (e *Exporter) copyAssets(ctx context.Context, html []byte) ([]byte, int, error) {
+	downloaded := 0
+	var firstErr error
+	html = storyblok.AssetURLPattern.ReplaceAllFunc(html, func(match []byte) []byte {
+		m := storyblok.AssetURLPattern.FindSubmatch(match)
+		host, rawPath := string(m[1]), string(m[2])
+		// html/template escapes the parentheses of image service filters
+		p, err := url.PathUnescape(rawPath)
+		if err != nil {
+			return match
+		}
+		p = path.Clean(p)
+		if !e.isAssetHost(host) || !filepath.IsLocal(filepath.FromSlash(strings.TrimPrefix(p, "/"))) {
+			return match
+		}
+		name := path.Join(host, p)
+		// image service variants go to a separate tree, the original is a file where the variants need a directory
+		if original, params, ok := strings.Cut(p, "/m/"); ok {
+			name = path.Join(host, "m", params, original)
+		}
+		fresh, err := e.download(ctx, "https://"+host+rawPath, filepath.Join(e.out, "assets", filepath.FromSlash(name)))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+		if fresh {
+			downloaded++
+		}
+		return []byte(strings.TrimSuffix(e.AssetPrefix, "/") + (&url.URL{Path: "/" + name}).EscapedPath())
+	})
+	return html, downloaded, firstErr
+}
+
+func (e *Exporter) isAssetHost(host string) bool {
+	for _, h := range e.AssetHosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// download fetches each asset once per export, assets already on disk are kept as asset URLs are immutable
+func (e *Exporter) download(ctx context.Context, url string, file string) (bool, error) {
+	e.assetsMu.Lock()
+	d, ok := e.assets[file]
+	if ok {
+		e.assetsMu.Unlock()
+		<-d.done
+		return false, d.err
+	}
+	d = &assetDownload{done: make(chan struct{})}
+	e.assets[file] = d
+	e.assetsMu.Unlock()
+	defer close(d.done)
+
+	if _, err := os.Stat(file); err == nil {
+		return false, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		d.err = err
+		return false, err
+	}
+	resp, err := e.HttpClient.Do(req)
+	if err != nil {
+		d.err = fmt.Errorf("asset %s: %w", url, err)
+		return false, d.err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		d.err = fmt.Errorf("asset %s: status: %d", url, resp.StatusCode)
+		return false, d.err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		d.err = fmt.Errorf("asset %s: %w", url, err)
+		return false, d.err
+	}
+	d.err = writeFile(file, body)
+	return d.err == nil, d.err
+}
+
+// writeFile replaces file atomically, so a running server never serves half written pages
+func writeFile(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
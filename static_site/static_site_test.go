@@ -0,0 +1,165 @@
+package static_site_test
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dryaf/headless_cms/cache/memory_cache"
+	"github.com/dryaf/headless_cms/client/storyblok"
+	"github.com/dryaf/headless_cms/static_site"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCDN answers CDN requests by path and language, and asset requests by host and path
+type fakeCDN struct {
+	responses map[string]string
+	requests  atomic.Int64
+}
+
+func (f *fakeCDN) Do(req *http.Request) (*http.Response, error) {
+	f.requests.Add(1)
+	key := req.URL.Host + req.URL.Path
+	if language := req.URL.Query().Get("language"); language != "" {
+		key += "?" + language
+	}
+	body, ok := f.responses[key]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+const home = `{"full_slug": "home", "published_at": "2024-01-01T00:00:00Z", "content": {"component": "page", "title": "Home", "image": "https://a.storyblok.com/f/1/hero.png"}}`
+const homeDE = `{"full_slug": "de/home", "default_full_slug": "home", "published_at": "2024-01-01T00:00:00Z", "content": {"component": "page", "title": "Startseite", "image": "https://a.storyblok.com/f/1/hero.png"}}`
+const post = `{"full_slug": "blog/post", "published_at": "2024-01-02T00:00:00Z", "content": {"component": "page", "title": "Post", "image": "https://example.com/x.png"}}`
+
+func newCDN() *fakeCDN {
+	return &fakeCDN{responses: map[string]string{
+		"api.storyblok.com/v2/cdn/stories":           `{"stories": [` + home + `,` + post + `]}`,
+		"api.storyblok.com/v2/cdn/stories?de":        `{"stories": [` + homeDE + `]}`,
+		"api.storyblok.com/v2/cdn/stories/home":      `{"story": ` + home + `}`,
+		"api.storyblok.com/v2/cdn/stories/home?de":   `{"story": ` + homeDE + `}`,
+		"api.storyblok.com/v2/cdn/stories/blog/post": `{"story": ` + post + `}`,
+		"a.storyblok.com/f/1/hero.png":               "png",
+	}}
+}
+
+func read(t *testing.T, name string) string {
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	out := t.TempDir()
+	cdn := newCDN()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	tmpl := template.Must(template.New("page").Parse(`<h1>{{.Story.content.title}}</h1><img src="{{.Story.content.image}}">`))
+
+	exporter := static_site.New(client, out, static_site.Template(tmpl, ""))
+	exporter.Languages = []string{"", "de"}
+	exporter.HttpClient = cdn
+
+	stats, err := exporter.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, static_site.Stats{Rendered: 3, Assets: 1}, stats)
+
+	assert.Equal(t, `<h1>Home</h1><img src="/assets/a.storyblok.com/f/1/hero.png">`, read(t, filepath.Join(out, "home", "index.html")))
+	assert.Equal(t, read(t, filepath.Join(out, "home", "index.html")), read(t, filepath.Join(out, "index.html")))
+	assert.Contains(t, read(t, filepath.Join(out, "de", "home", "index.html")), "Startseite")
+	assert.Contains(t, read(t, filepath.Join(out, "de", "index.html")), "Startseite")
+	assert.Contains(t, read(t, filepath.Join(out, "blog", "post", "index.html")), `src="https://example.com/x.png"`)
+	assert.Equal(t, "png", read(t, filepath.Join(out, "assets", "a.storyblok.com", "f", "1", "hero.png")))
+
+	// unchanged stories are skipped, deleted ones removed
+	cdn.responses["api.storyblok.com/v2/cdn/stories"] = `{"stories": [` + home + `]}`
+	stats, err = exporter.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, static_site.Stats{Skipped: 2, Removed: 1}, stats)
+	assert.NoFileExists(t, filepath.Join(out, "blog", "post", "index.html"))
+}
+
+func TestExportSkippedAndRendered(t *testing.T) {
+	ctx := context.Background()
+	out := t.TempDir()
+	cdn := &fakeCDN{responses: map[string]string{}}
+	listing := func(published string) {
+		stories := []string{}
+		for i := 0; i < 20; i++ {
+			date := "2024-01-01T00:00:00Z"
+			if i%2 == 1 {
+				date = published
+			}
+			s := fmt.Sprintf(`{"full_slug": "post-%d", "published_at": %q, "content": {"component": "page", "title": "Post"}}`, i, date)
+			stories = append(stories, s)
+			cdn.responses[fmt.Sprintf("api.storyblok.com/v2/cdn/stories/post-%d", i)] = `{"story": ` + s + `}`
+		}
+		cdn.responses["api.storyblok.com/v2/cdn/stories"] = `{"stories": [` + strings.Join(stories, ",") + `]}`
+	}
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	exporter := static_site.New(client, out, static_site.Template(template.Must(template.New("page").Parse(`{{.Story.content.title}}`)), ""))
+	exporter.Languages = []string{""}
+	exporter.Concurrency = 4
+
+	listing("2024-01-01T00:00:00Z")
+	stats, err := exporter.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 20, stats.Rendered)
+
+	// every other story changed, skipped and rendered jobs interleave
+	listing("2024-02-01T00:00:00Z")
+	stats, err = exporter.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, static_site.Stats{Rendered: 10, Skipped: 10}, stats)
+}
+
+func TestExportRenderError(t *testing.T) {
+	ctx := context.Background()
+	out := t.TempDir()
+	cdn := newCDN()
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	tmpl := template.Must(template.New("article").Parse(`article`))
+
+	exporter := static_site.New(client, out, static_site.Template(tmpl, ""))
+	exporter.Languages = []string{""}
+	stats, err := exporter.Export(ctx)
+	require.Error(t, err)
+	assert.Equal(t, 2, stats.Failed)
+	assert.NoFileExists(t, filepath.Join(out, "home", "index.html"))
+}
+
+func TestExportImageServiceURLs(t *testing.T) {
+	ctx := context.Background()
+	out := t.TempDir()
+	cdn := newCDN()
+	cdn.responses["a.storyblok.com/f/1/hero.png/m/800x0/filters:format(webp)"] = "webp"
+	cdn.responses["a.storyblok.com/f/1/hero.png/m/400x0"] = "small"
+	client := storyblok.NewClient(ctx, "test_token", "empty_cache_token", memory_cache.New(), cdn)
+	tmpl := template.New("page")
+	tmpl = template.Must(tmpl.Funcs(client.FuncMap(tmpl)).Parse(
+		`<img src="{{((img .Story.content.image).Resize 800 0).Format "webp"}}" srcset="{{srcset .Story.content.image 400}}"><a href="{{.Story.content.image}}">`))
+
+	exporter := static_site.New(client, out, static_site.Template(tmpl, ""))
+	exporter.Languages = []string{""}
+	exporter.HttpClient = cdn
+	stats, err := exporter.Export(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Assets)
+
+	html := read(t, filepath.Join(out, "home", "index.html"))
+	assert.Equal(t, `<img src="/assets/a.storyblok.com/m/800x0/filters:format%28webp%29/f/1/hero.png"`+
+		` srcset="/assets/a.storyblok.com/m/400x0/f/1/hero.png 400w"><a href="/assets/a.storyblok.com/f/1/hero.png">`, html)
+	assert.Equal(t, "webp", read(t, filepath.Join(out, "assets", "a.storyblok.com", "m", "800x0", "filters:format(webp)", "f", "1", "hero.png")))
+	assert.Equal(t, "png", read(t, filepath.Join(out, "assets", "a.storyblok.com", "f", "1", "hero.png")))
+}